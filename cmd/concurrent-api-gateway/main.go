@@ -1,13 +1,119 @@
 package main
 
 import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
 	handlers "github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/handlers"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/config"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tracing"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
+	router := gin.New()
+	// Log lines written with a request's context carry its trace_id and span_id.
+	logger := slog.New(tracing.LogHandler(slog.NewJSONHandler(os.Stdout, nil)))
+	slog.SetDefault(logger)
+	// One JSON log line per request, tagged with an X-Request-ID that is also forwarded downstream.
+	// An inbound traceparent is continued, so the request's spans and log lines share its trace id.
+	router.Use(middleware.RequestLogger(logger), middleware.TraceContext(), gin.Recovery(), middleware.CountActive())
+	// A request that has looped through the gateway more than 5 times is cut off with a 508.
+	router.Use(middleware.LoopGuard(5))
+	// Gzip responses of 1KiB or more for clients that accept it.
+	router.Use(middleware.Gzip(1024))
+
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	for name, fetch := range cfg.FetchConfigs {
+		service.MergeFetchConfig(name, fetch)
+	}
+	for name, escalation := range cfg.TimeoutEscalations {
+		service.SetTimeoutEscalation(name, escalation)
+	}
+	// Queued downstream calls get slots by their request's admission priority (see below).
+	service.SetMaxConcurrency(cfg.MaxOutbound)
+	// BREAKER_GROUPING=host makes services on one host trip and fail fast together.
+	service.SetBreakerGrouping(cfg.BreakerGrouping)
+	for name, timeout := range cfg.ProbeTimeouts {
+		if breaker, ok := service.BreakerFor(name); ok {
+			breaker.SetProbeTimeout(timeout)
+		}
+	}
+	for name, other := range cfg.FallbackServices {
+		service.SetFallbackService(name, other)
+	}
+	for host, fingerprints := range cfg.PinnedKeys {
+		service.SetPinnedKeys(host, fingerprints...)
+	}
+	for name, policy := range cfg.CacheWrites {
+		service.ResponseCache.SetWritePolicy(name, policy)
+	}
+	for name, window := range cfg.StaleIfError {
+		service.ResponseCache.SetStaleIfError(name, window)
+	}
+	for name, correlation := range cfg.Correlations {
+		service.SetCorrelation(name, correlation)
+	}
+	handlers.SetCallbackHosts(cfg.AsyncCallbackHosts...)
+	handlers.SetOutageRatio(cfg.OutageRatio)
+	service.SetHealthScoreConfig(cfg.HealthScore)
+	for name, urls := range cfg.PreloadHints {
+		handlers.SetPreloadHints(name, urls...)
+	}
+	for name, variants := range cfg.ResponseTemplates {
+		fetcher, _ := service.Default.Get(name)
+		service.Default.Register(name, variants.Wrap(fetcher))
+	}
+
+	// OTEL_EXPORTER_OTLP_ENDPOINT (e.g. "http://localhost:4318" for Jaeger) turns on request tracing.
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		shutdownTracing, err := tracing.Init("concurrent-api-gateway", endpoint)
+		if err != nil {
+			logger.Error("tracing setup failed", "error", err)
+			os.Exit(1)
+		}
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Error("flushing traces failed", "error", err)
+			}
+		}()
+	}
+
+	// An empty notification list is an acceptable answer when the service is down or slow.
+	service.SetFallback("notifications", func(userID string) any {
+		return map[string]any{"service": "notifications", "userId": userID, "unread": 0, "messages": []any{}}
+	})
+
+	// Inbound headers copied onto every downstream call, up to FORWARD_HEADERS_MAX_BYTES in all.
+	service.SetForwardHeaders([]string{"Authorization", "X-Trace-Id"})
+	router.Use(middleware.ForwardHeaders(cfg.ForwardHeadersMaxBytes, logger))
+
+	// Directory of per-user <userID>.json aggregates served when every downstream is down.
+	service.SetSnapshotDir(os.Getenv("SNAPSHOT_DIR"))
+
+	// GATEWAY_OFFLINE=true serves every downstream from seeded in-memory data (no mock service needed).
+	if os.Getenv("GATEWAY_OFFLINE") == "true" {
+		service.SeedFakes()
+		service.SetOffline(true)
+	}
 
 	router.GET("/health", func(ctx *gin.Context) {
 		m := map[string]string{
@@ -18,11 +124,141 @@ func main() {
 
 	})
 
-	router.GET("/api/aggregate/wg", handlers.AggregateHandler)
+	// Probes every downstream; 503 unless all of CRITICAL_SERVICES are reachable.
+	router.GET("/health/ready", handlers.ReadyHandler(cfg.CriticalServices))
+
+	// Which build is deployed: version, commit and build time from -ldflags (see the makefile),
+	// the Go version, and the optional features this instance was started with.
+	router.GET("/version", handlers.VersionHandler(map[string]bool{
+		"tracing":            os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "",
+		"jwt_auth":           cfg.AuthEnabled(),
+		"offline":            os.Getenv("GATEWAY_OFFLINE") == "true",
+		"snapshots":          os.Getenv("SNAPSHOT_DIR") != "",
+		"trust_proxy":        os.Getenv("TRUST_PROXY") == "true",
+		"response_templates": len(cfg.ResponseTemplates) > 0,
+	}))
+
+	// Prometheus scrape endpoint: aggregate latency, per-service outcomes, in-flight requests.
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	// At most 256 aggregates run at once; up to 1024 more wait, premium and authenticated
+	// callers first, and the lowest-priority waiters are shed when the queue overflows.
+	admission := middleware.NewAdmission(256, 1024, middleware.TokenPriority)
+
+	// Each client IP gets RATE_LIMIT_RPS requests a second (default 20) in bursts of up to
+	// RATE_LIMIT_BURST (default 40). Set TRUST_PROXY=true behind a proxy that sets X-Forwarded-For.
+	perSecond, burst := 20.0, 40
+	if raw := os.Getenv("RATE_LIMIT_RPS"); raw != "" {
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v <= 0 {
+			logger.Error("invalid RATE_LIMIT_RPS", "value", raw)
+			os.Exit(1)
+		}
+		perSecond = v
+	}
+	if raw := os.Getenv("RATE_LIMIT_BURST"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 1 {
+			logger.Error("invalid RATE_LIMIT_BURST", "value", raw)
+			os.Exit(1)
+		}
+		burst = v
+	}
+	limiter := middleware.NewRateLimiter(perSecond, burst, os.Getenv("TRUST_PROXY") == "true")
+
+	// The aggregate routes need a bearer token signed with JWT_SECRET or one of the JWT_KEYS; its
+	// subject is the default user_id. Those listed in ROUTE_SCOPES also need the token to hold the scopes.
+	// The admin routes also need the token to hold the "admin" role, and aren't mounted at all
+	// with AUTH_DISABLED=true. /health and /metrics stay open.
+	authenticate := gin.HandlerFunc(func(c *gin.Context) { c.Next() })
+	scopes, refresh := authenticate, authenticate
+	if cfg.AuthEnabled() {
+		tokenService := tokens.NewService([]byte(cfg.JWTSecret))
+		if len(cfg.JWTKeys) > 0 {
+			keys := map[string][]byte{"": []byte(cfg.JWTSecret)}
+			for kid, secret := range cfg.JWTKeys {
+				keys[kid] = []byte(secret)
+			}
+			if err := tokenService.SetKeys(keys, cfg.JWTSigningKey); err != nil {
+				logger.Error("invalid JWT keys", "error", err)
+				os.Exit(1)
+			}
+		}
+		tokenService.SetLeeway(cfg.JWTLeeway)
+		// Logging out revokes the token until it expires; ids revoked by hand are kept a day.
+		tokenService.SetRevocations(tokens.NewMemoryRevocations(), 24*time.Hour)
+		authenticate = middleware.Authenticate(tokenService)
+		scopes = middleware.RequireRouteScopes(cfg.RouteScopes)
+		if cfg.JWTRefreshWindow > 0 {
+			// Tokens within JWT_REFRESH_WINDOW of expiry are swapped for hour-long ones, returned in X-Refreshed-Token.
+			refresh = middleware.RefreshNearExpiry(tokenService, cfg.JWTRefreshWindow, time.Hour)
+		}
+
+		// A token can be swapped for a fresh hour-long one from 5 minutes before it expires
+		// until a minute after. The route sits outside authenticate, which rejects expired tokens.
+		refresher := tokens.NewRefresher(tokenService, 5*time.Minute, time.Minute)
+		router.POST("/auth/refresh", limiter.Middleware(), handlers.RefreshHandler(refresher, time.Hour))
+		router.POST("/auth/logout", limiter.Middleware(), authenticate, handlers.LogoutHandler(tokenService))
+	} else {
+		// Only reachable with AUTH_DISABLED=true: config.Load refuses to run unauthenticated otherwise.
+		logger.Warn("AUTH_DISABLED is set; the aggregate routes are unauthenticated and the admin routes are off")
+	}
+
+	// Admins can switch features for one request with X-Feature-Override, e.g. "cache=off,strategy=channels",
+	// and point its downstream calls at a test backend with X-Upstream-Override, e.g. "http://test-host:9090".
+	overrides := middleware.FeatureOverrides("admin")
+	aggregate := router.Group("/api/aggregate", limiter.Middleware(), authenticate, refresh, scopes, overrides, admission.Middleware())
+
+	// ?services=user,orders aggregates just those services; empty means all of them.
+	aggregate.GET("", handlers.AggregateServicesHandler)
+
+	// POST writes to the services named in the body, keeping the response cache in step.
+	aggregate.POST("", handlers.AggregateWriteHandler)
 
-	router.GET("/api/aggregate/channel", handlers.AggregateChannelHandler)
+	// POST /async runs an aggregation in the background and POSTs the result to a callback URL.
+	aggregate.POST("/async", handlers.AggregateAsyncHandler)
+	aggregate.GET("/async/:id", handlers.AggregateJobHandler)
+
+	aggregate.GET("/wg", handlers.WithStrategyOverride(handlers.AggregateHandler))
+
+	aggregate.GET("/channel", handlers.WithStrategyOverride(handlers.AggregateChannelHandler))
+
+	aggregate.GET("/channel-with-context-timeout", handlers.WithStrategyOverride(handlers.AggregateHandlerWithTimeout))
+
+	// Responds at the 1s deadline with whatever has completed, listing the rest as timed out.
+	aggregate.GET("/best-effort", handlers.WithStrategyOverride(handlers.AggregateBestEffortHandler))
+
+	if cfg.AuthEnabled() {
+		admin := router.Group("/admin", authenticate, middleware.RequireRole("admin"))
+		admin.GET("/slowest", handlers.AdminSlowestHandler)
+		admin.GET("/health-scores", handlers.AdminHealthScoresHandler)
+		admin.GET("/pool-stats", handlers.AdminPoolStatsHandler)
+		admin.POST("/services/:name/pause", handlers.AdminPauseServiceHandler)
+		admin.POST("/services/:name/resume", handlers.AdminResumeServiceHandler)
+	}
+
+	// SHUTDOWN_GRACE (e.g. "30s") is how long in-flight requests get to finish on SIGINT/SIGTERM.
+	grace := 10 * time.Second
+	if raw := os.Getenv("SHUTDOWN_GRACE"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			logger.Error("invalid SHUTDOWN_GRACE", "value", raw, "error", err)
+			os.Exit(1)
+		}
+		grace = d
+	}
 
-	router.GET("/api/aggregate/channel-with-context-timeout", handlers.AggregateHandlerWithTimeout)
+	srv := &http.Server{Addr: ":8080", Handler: router}
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		logger.Error("listen failed", "addr", srv.Addr, "error", err)
+		os.Exit(1)
+	}
 
-	router.Run(":8080")
+	stop, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	if err := serve(stop, srv, ln, grace, logger); err != nil {
+		logger.Error("server failed", "error", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+)
+
+// serve runs srv on ln until ctx is done, then shuts it down gracefully: ln stops accepting
+// connections at once, and in-flight requests get up to grace to finish. A shutdown that
+// runs out of grace is logged, not returned; the error is srv failing to serve at all.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, grace time.Duration, logger *slog.Logger) error {
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down", "active_requests", middleware.ActiveRequests(), "grace", grace.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("shutdown did not finish in time", "active_requests", middleware.ActiveRequests(), "error", err)
+		return nil
+	}
+	logger.Info("shutdown complete")
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeFinishesInFlightRequestsAndRefusesNewOnes(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		io.WriteString(w, "done")
+	})}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+
+	ctx, stop := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, srv, ln, 5*time.Second, slog.New(slog.DiscardHandler)) }()
+
+	type response struct {
+		body string
+		err  error
+	}
+	inFlight := make(chan response, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr)
+		if err != nil {
+			inFlight <- response{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		inFlight <- response{string(body), err}
+	}()
+	<-started
+	stop()
+
+	// The listener closes at once, so new connections are refused while the request runs.
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("still accepting connections a second into shutdown")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case err := <-served:
+		t.Fatalf("serve returned %v with a request still in flight", err)
+	default:
+	}
+
+	close(release)
+	if got := <-inFlight; got.err != nil || got.body != "done" {
+		t.Fatalf("in-flight request got %q, %v; want it to finish", got.body, got.err)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("serve() = %v, want nil after a clean shutdown", err)
+	}
+}
@@ -3,32 +3,76 @@ package main
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+// writes holds the fields POSTed to each path, which later GETs of it return over their defaults.
+var writes = struct {
+	sync.Mutex
+	fields map[string]gin.H
+}{fields: make(map[string]gin.H)}
+
+// withWrites returns data with the fields written to the request's path laid over it.
+func withWrites(c *gin.Context, data gin.H) gin.H {
+	writes.Lock()
+	defer writes.Unlock()
+	for k, v := range writes.fields[c.Request.URL.Path] {
+		data[k] = v
+	}
+	return data
+}
+
+// write stores the JSON object in the request body for the request's path and answers with the
+// path's data as it is after the write, which is what a GET of it now returns.
+func write(get gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var body gin.H
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(400, gin.H{"error": "body must be a JSON object"})
+			return
+		}
+		writes.Lock()
+		if writes.fields[c.Request.URL.Path] == nil {
+			writes.fields[c.Request.URL.Path] = gin.H{}
+		}
+		for k, v := range body {
+			writes.fields[c.Request.URL.Path][k] = v
+		}
+		writes.Unlock()
+		get(c)
+	}
+}
+
+// handle registers get for GET on path, and a POST on it that writes to what get returns.
+func handle(r *gin.Engine, path string, get gin.HandlerFunc) {
+	r.GET(path, get)
+	r.POST(path, write(get))
+}
+
 func main() {
 	r := gin.Default()
 	// Note: rand.Seed is no longer needed in Go 1.20+
 	// The global random number generator is automatically seeded
 
 	// Mock service 1: User Service
-	r.GET("/mock/user/:id", func(c *gin.Context) {
+	handle(r, "/mock/user/:id", func(c *gin.Context) {
 		time.Sleep(time.Duration(rand.Intn(100)) * time.Millisecond) // Random delay
-		c.JSON(200, gin.H{
+		c.JSON(200, withWrites(c, gin.H{
 			"service":   "user",
 			"id":        c.Param("id"),
 			"name":      "John Doe",
 			"email":     "john@example.com",
 			"timestamp": time.Now().Unix(),
-		})
+		}))
 	})
 
 	// Mock service 2: Order Service
-	r.GET("/mock/orders/:userId", func(c *gin.Context) {
+	handle(r, "/mock/orders/:userId", func(c *gin.Context) {
 		time.Sleep(time.Duration(rand.Intn(200)) * time.Millisecond)
-		c.JSON(200, gin.H{
+		c.JSON(200, withWrites(c, gin.H{
 			"service": "orders",
 			"userId":  c.Param("userId"),
 			"orders": []gin.H{
@@ -36,31 +80,31 @@ func main() {
 				{"id": "ORD002", "total": 149.99},
 			},
 			"timestamp": time.Now().Unix(),
-		})
+		}))
 	})
 
 	// Mock service 3: Notification Service
-	r.GET("/mock/notifications/:userId", func(c *gin.Context) {
+	handle(r, "/mock/notifications/:userId", func(c *gin.Context) {
 		time.Sleep(time.Duration(rand.Intn(200)) * time.Millisecond)
-		c.JSON(200, gin.H{
+		c.JSON(200, withWrites(c, gin.H{
 			"service":   "notifications",
 			"userId":    c.Param("userId"),
 			"unread":    3,
 			"messages":  []string{"Welcome back!", "Order shipped", "New feature available"},
 			"timestamp": time.Now().Unix(),
-		})
+		}))
 	})
 
 	// Mock service 4: Inventory Service (for later)
-	r.GET("/mock/inventory/:productId", func(c *gin.Context) {
+	handle(r, "/mock/inventory/:productId", func(c *gin.Context) {
 		time.Sleep(time.Duration(rand.Intn(80)) * time.Millisecond)
-		c.JSON(200, gin.H{
+		c.JSON(200, withWrites(c, gin.H{
 			"service":   "inventory",
 			"productId": c.Param("productId"),
 			"stock":     rand.Intn(100),
 			"price":     49.99,
 			"timestamp": time.Now().Unix(),
-		})
+		}))
 	})
 
 	println("Mock services running on :9090")
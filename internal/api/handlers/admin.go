@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AdminSlowestHandler reports, per time bucket, which service had the highest p95 latency.
+// The look-back is taken from ?window= (any time.ParseDuration value, e.g. 5m, 1h) and defaults to 5m.
+func AdminSlowestHandler(c *gin.Context) {
+	window, ok := parseWindow(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"window":  window.String(),
+		"buckets": service.Stats.Slowest(window),
+	})
+}
+
+// AdminHealthScoresHandler reports a 0-100 health score per service, combining its
+// error rate and p95 latency against its SLO over ?window= (default 5m).
+func AdminHealthScoresHandler(c *gin.Context) {
+	window, ok := parseWindow(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"window": window.String(),
+		"scores": service.HealthScores(window),
+	})
+}
+
+// AdminPoolStatsHandler reports outbound connection pool stats per downstream host
+// (connections created, active, idle and how many requests reused a connection).
+func AdminPoolStatsHandler(c *gin.Context) {
+	c.JSON(200, gin.H{"hosts": service.PoolStats()})
+}
+
+// AdminPauseServiceHandler puts a service into maintenance: the gateway stops calling it
+// and returns {"maintenance": true} as its data until it is resumed. An unknown service is a 404.
+func AdminPauseServiceHandler(c *gin.Context) {
+	name, ok := registeredService(c)
+	if !ok {
+		return
+	}
+	service.Pause(name)
+	c.JSON(200, gin.H{"service": name, "paused": true})
+}
+
+// AdminResumeServiceHandler ends a service's maintenance window. An unknown service is a 404.
+func AdminResumeServiceHandler(c *gin.Context) {
+	name, ok := registeredService(c)
+	if !ok {
+		return
+	}
+	service.Resume(name)
+	c.JSON(200, gin.H{"service": name, "paused": false})
+}
+
+// registeredService returns the :name path parameter if it is a service in service.Default.
+// Otherwise it writes a 404 response and returns false.
+func registeredService(c *gin.Context) (string, bool) {
+	name := c.Param("name")
+	if _, ok := service.Default.Get(name); !ok {
+		c.JSON(404, gin.H{"error": "unknown service " + name})
+		return "", false
+	}
+	return name, true
+}
+
+// parseWindow reads ?window= as a positive duration, defaulting to 5m.
+// On a bad value it writes a 400 response and returns false.
+func parseWindow(c *gin.Context) (time.Duration, bool) {
+	window := 5 * time.Minute
+	if raw := c.Query("window"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			c.JSON(400, gin.H{"error": "invalid window: " + raw})
+			return 0, false
+		}
+		window = d
+	}
+	return window, true
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminPauseAndResumeService(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"u1"}`))
+	}))
+	defer srv.Close()
+	service.SetFetchConfig("paused-svc", service.FetchConfig{BaseURL: srv.URL})
+	defer service.SetFetchConfig("paused-svc", service.FetchConfig{})
+	defer service.Resume("paused-svc")
+	useServices(t, map[string]service.Fetcher{"paused-svc": service.HTTPFetcher("paused-svc", "/users/")})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/admin/services/:name/pause", AdminPauseServiceHandler)
+	router.POST("/admin/services/:name/resume", AdminResumeServiceHandler)
+	router.GET("/wg", AggregateHandler)
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+	data := func() map[string]any {
+		t.Helper()
+		w := do(http.MethodGet, "/wg?user_id=u1")
+		if w.Code != http.StatusOK {
+			t.Fatalf("aggregate status = %d: %s", w.Code, w.Body)
+		}
+		return decode(t, w)["data"].(map[string]any)["paused-svc"].(map[string]any)
+	}
+
+	if w := do(http.MethodPost, "/admin/services/paused-svc/pause"); w.Code != http.StatusOK {
+		t.Fatalf("pause status = %d: %s", w.Code, w.Body)
+	}
+	if got := data(); got["maintenance"] != true {
+		t.Fatalf("paused data = %v, want the maintenance marker", got)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("paused service called %d times, want 0", n)
+	}
+
+	if w := do(http.MethodPost, "/admin/services/paused-svc/resume"); w.Code != http.StatusOK {
+		t.Fatalf("resume status = %d: %s", w.Code, w.Body)
+	}
+	if got := data(); got["id"] != "u1" || got["maintenance"] != nil {
+		t.Fatalf("resumed data = %v, want the service's own", got)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("resumed service called %d times, want 1", n)
+	}
+
+	if w := do(http.MethodPost, "/admin/services/nope/pause"); w.Code != http.StatusNotFound {
+		t.Fatalf("pausing an unknown service: status = %d, want 404", w.Code)
+	}
+}
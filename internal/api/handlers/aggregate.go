@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/status"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/transform"
+	"github.com/gin-gonic/gin"
+)
+
+// aggregateRun is what an aggregate handler read from its request before fanning out.
+// The handlers differ only in how they fan out and collect; everything around that is shared
+// through beginAggregate and finishAggregate.
+type aggregateRun struct {
+	userID   string
+	services map[string]service.Fetcher // after ?sample
+	required int                        // ?min_success
+	pipeline transform.Func             // ?pipeline, nil if absent
+	retryLog *service.RetryLog          // ?debug_retries, nil if absent
+	fresh    *service.FreshnessLog      // ?freshness, nil if absent
+	timer    *phaseTimer
+	start    time.Time
+}
+
+// beginAggregate reads the query parameters every aggregate handler shares, for a fan-out over
+// servicesToCall: ?max_age and the client type are stored on the request context, ?debug_timing,
+// ?debug_retries and ?freshness start timing phases and recording retries and where data came
+// from, ?sample picks the services, and ?min_success and ?pipeline are parsed.
+// It swaps the request's context, so call it before deriving the fan-out's context from it.
+// A bad parameter writes a 400, too many of the picked services' breakers being open a 503 (see
+// SetOutageRatio), and ok is false.
+func beginAggregate(c *gin.Context, servicesToCall map[string]service.Fetcher) (run *aggregateRun, ok bool) {
+	run = &aggregateRun{userID: requestUserID(c), start: time.Now(), timer: startPhases(c)}
+	if !authorizeUser(c, run.userID) {
+		return nil, false
+	}
+	if !maxAge(c) {
+		return nil, false
+	}
+	clientType(c)
+	run.retryLog = trackRetries(c)
+	run.fresh = trackFreshness(c)
+
+	if run.services, ok = sampleServices(c, servicesToCall); !ok {
+		return nil, false
+	}
+	if run.required, ok = minSuccess(c, len(run.services)); !ok {
+		return nil, false
+	}
+	if run.pipeline, ok = pipelineFor(c); !ok {
+		return nil, false
+	}
+	if !checkOutage(c, run.services) {
+		return nil, false
+	}
+	return run, true
+}
+
+// outcomes collects what each service of one aggregate came back with. It isn't safe for
+// concurrent use: handlers recording from several goroutines hold a lock around record.
+//
+// A failed service served from its fallback (see service.SetFallback) is degraded, not a success:
+// its data is shown, but it counts as failed for ?min_success, the snapshot, the every-service-failed
+// 502, the metrics and ?grouped=true.
+type outcomes struct {
+	results   map[string]any    // service -> data, for services that answered
+	failures  map[string]string // service -> error, for every service that failed
+	fallbacks map[string]any    // service -> fallback data, for failed services that have one
+}
+
+func newOutcomes() *outcomes {
+	return &outcomes{
+		results:   make(map[string]any),
+		failures:  make(map[string]string),
+		fallbacks: make(map[string]any),
+	}
+}
+
+// record adds one service's result, called with id. A failed service with a fallback also gets
+// the fallback's data.
+func (o *outcomes) record(res result, id string) {
+	if res.err == nil {
+		o.results[res.service] = res.data
+		return
+	}
+	o.failures[res.service] = res.err.Error()
+	if data, ok := service.Fallback(res.service, id); ok {
+		o.fallbacks[res.service] = data
+	}
+}
+
+// data returns the data to show: every answer, plus the fallback data of failed services.
+func (o *outcomes) data() map[string]any {
+	data := make(map[string]any, len(o.results)+len(o.fallbacks))
+	for name, v := range o.results {
+		data[name] = v
+	}
+	for name, v := range o.fallbacks {
+		data[name] = v
+	}
+	return data
+}
+
+// errors returns "<service>: <error>" for every failed service without a fallback, sorted, so the
+// same outcome always produces the same response whatever order the services finished in.
+func (o *outcomes) errors() []string {
+	errors := make([]string, 0, len(o.failures))
+	for name, msg := range o.failures {
+		if _, ok := o.fallbacks[name]; !ok {
+			errors = append(errors, name+": "+msg)
+		}
+	}
+	sort.Strings(errors)
+	return errors
+}
+
+// fallbacksUsed returns the services served from their fallback.
+func (o *outcomes) fallbacksUsed() []string {
+	names := make([]string, 0, len(o.fallbacks))
+	for name := range o.fallbacks {
+		names = append(names, name)
+	}
+	return names
+}
+
+// failed returns every failed service, fallback or not.
+func (o *outcomes) failed() []string {
+	names := make([]string, 0, len(o.failures))
+	for name := range o.failures {
+		names = append(names, name)
+	}
+	return names
+}
+
+// finishAggregate builds the response from the collected outcomes and writes it. resp holds the
+// handler's own fields (e.g. "concurrency"); data, errors and duration_ms are added here, then
+// every response feature runs in order:
+//   - the snapshot, when every service failed
+//   - status: unless the snapshot stands in, 502 when every service failed or fewer than
+//     ?min_success (default 1) did, counting only services that answered as succeeded
+//   - checksums, ?fields, ?pipeline, ?compress_services, ?dedup and ?grouped
+//   - meta: queue wait, retries and freshness, fallbacks_used, the Link preload hints, and last
+//     the phases
+func finishAggregate(c *gin.Context, run *aggregateRun, out *outcomes, resp gin.H) {
+	run.timer.mark("collect")
+	countOutcomes(out.results, out.failures)
+
+	resp["data"] = out.data()
+	resp["errors"] = out.errors()
+	resp["duration_ms"] = time.Since(run.start).Milliseconds()
+
+	code := http.StatusOK
+	if len(out.failures) > 0 {
+		withSnapshot(resp, run.userID, len(out.results))
+	}
+	switch {
+	case resp["source"] == "snapshot":
+		// The snapshot stands in for the failed services, ?min_success included.
+	case status.FromResults(out.results, out.failed()) == http.StatusBadGateway:
+		// Every service failing is a 502, fallbacks or not.
+		code = http.StatusBadGateway
+		resp["error"] = "every service failed"
+	default:
+		code = aggregateStatus(resp, len(out.results), run.required)
+	}
+	withChecksums(resp)
+	withFields(c, resp)
+	code = withPipeline(c, resp, run.pipeline, code)
+	withCompression(c, resp)
+	withDedup(c, resp)
+	withOrder(c, resp, out)
+	withGrouping(c, resp, out)
+	withQueueWait(c, resp)
+	withFallbacks(resp, out.fallbacksUsed())
+	withRetries(resp, run.retryLog, run.services)
+	withFreshness(resp, run.fresh, out)
+	withPreloadHints(c, out.results)
+	withPhases(c, resp, run.timer)
+	c.JSON(code, resp)
+}
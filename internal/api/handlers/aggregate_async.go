@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/status"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/jobs"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// asyncJobs runs the aggregations submitted to AggregateAsyncHandler: four at a time, with up to 64
+// more waiting, each cut off after 30s.
+var asyncJobs = jobs.NewManager(4, 64, 30*time.Second)
+
+// SetCallbackHosts limits the callback_url AggregateAsyncHandler accepts to the given hosts;
+// with none, any public address is accepted (see jobs.Manager.SetCallbackHosts).
+func SetCallbackHosts(hosts ...string) {
+	asyncJobs.SetCallbackHosts(hosts...)
+}
+
+// asyncRequest is the body of POST /api/aggregate/async.
+type asyncRequest struct {
+	CallbackURL string `json:"callback_url" binding:"required"`
+	UserID      string `json:"user_id"`
+	Services    string `json:"services"` // comma-separated, as in ?services=; empty means all
+}
+
+// AggregateAsyncHandler accepts an aggregation to run in the background and answers 202 with
+// its job id straight away. When the aggregation is done its job, result included, is POSTed
+// to callback_url; until then (and for an hour after) it can be looked up with AggregateJobHandler.
+// A missing, non-http(s) or disallowed callback_url (see SetCallbackHosts), or an unknown service,
+// is a 400; a full job queue is a 429.
+func AggregateAsyncHandler(c *gin.Context) {
+	var req asyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "body must be a JSON object with a callback_url"})
+		return
+	}
+	if err := asyncJobs.CheckCallback(req.CallbackURL); err != nil {
+		c.JSON(400, gin.H{"error": "callback_url: " + err.Error()})
+		return
+	}
+	if req.UserID == "" {
+		req.UserID = requestUserID(c)
+	}
+	if !authorizeUser(c, req.UserID) {
+		return
+	}
+
+	servicesToCall, unknown := requestedServices(req.Services)
+	if unknown != "" {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("unknown service %q", unknown)})
+		return
+	}
+	ids := idsFor(c, servicesToCall, req.UserID)
+
+	// The job keeps the request context's values, so it reshapes responses for this client too.
+	clientType(c)
+	var owner string
+	if claims, ok := middleware.Claims(c); ok {
+		owner = claims.Subject
+	}
+	job, err := asyncJobs.Submit(c.Request.Context(), owner, req.CallbackURL, func(ctx context.Context) (any, error) {
+		return aggregateInBackground(ctx, servicesToCall, ids)
+	})
+	if errors.Is(err, jobs.ErrCallbackNotAllowed) {
+		c.JSON(400, gin.H{"error": "callback_url: " + err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(429, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(202, gin.H{
+		"job_id":     job.ID,
+		"status":     job.Status,
+		"status_url": "/api/aggregate/async/" + job.ID,
+	})
+}
+
+// AggregateJobHandler returns the job submitted to AggregateAsyncHandler under :id, or a 404.
+// An authenticated caller without the "admin" role only sees the jobs they submitted; anyone
+// else's is a 404 too, so job ids can't be probed.
+func AggregateJobHandler(c *gin.Context) {
+	job, ok := asyncJobs.Get(c.Param("id"))
+	if claims, authenticated := middleware.Claims(c); ok && authenticated && !claims.HasRole("admin") {
+		ok = job.Owner == claims.Subject
+	}
+	if !ok {
+		c.JSON(404, gin.H{"error": "job not found"})
+		return
+	}
+	c.JSON(200, job)
+}
+
+// aggregateInBackground fans out to servicesToCall like the synchronous handlers, calling each with
+// its id from ids, and returns the same data/errors shape. It fails only if every service failed.
+func aggregateInBackground(ctx context.Context, servicesToCall map[string]service.Fetcher, ids map[string]string) (any, error) {
+	start := time.Now()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	out := newOutcomes()
+
+	for name, fetcher := range servicesToCall {
+		wg.Add(1)
+		go func(name, id string, fetcher service.Fetcher) {
+			defer wg.Done()
+
+			data, err := tracedFetch(ctx, name, id, fetcher)
+			mu.Lock()
+			defer mu.Unlock()
+			out.record(result{service: name, data: data, err: err}, id)
+		}(name, ids[name], fetcher)
+	}
+	wg.Wait()
+	countOutcomes(out.results, out.failures)
+
+	resp := gin.H{
+		"data":        out.data(),
+		"errors":      out.errors(),
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	withFallbacks(resp, out.fallbacksUsed())
+	if status.FromResults(out.results, out.failed()) != 200 {
+		return resp, fmt.Errorf("every service failed")
+	}
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/jobs"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAsyncAggregateCallsBackAndOnlyShowsJobsToTheirOwner(t *testing.T) {
+	useServices(t, map[string]service.Fetcher{
+		"async-user": func(ctx context.Context, userID string) (any, error) { return "user " + userID, nil },
+	})
+	got := make(chan jobs.Job, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var job jobs.Job
+		if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+			t.Errorf("callback body: %v", err)
+		}
+		got <- job
+	}))
+	defer receiver.Close()
+	SetCallbackHosts("127.0.0.1")
+	defer SetCallbackHosts()
+
+	svc := tokens.NewService([]byte("test-secret"))
+	token := func(subject string, roles ...string) string {
+		t.Helper()
+		tok, err := svc.CreateTokenWithClaims(subject, roles, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+	alice, bob, admin := token("alice"), token("bob"), token("root", "admin")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/aggregate/async", middleware.Authenticate(svc), AggregateAsyncHandler)
+	router.GET("/api/aggregate/async/:id", middleware.Authenticate(svc), AggregateJobHandler)
+	call := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call(http.MethodPost, "/api/aggregate/async", alice, `{"callback_url": "`+receiver.URL+`/done"}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("submit status = %d: %s", w.Code, w.Body)
+	}
+	submitted := decode(t, w)
+	if submitted["status"] != string(jobs.StatusQueued) {
+		t.Fatalf("submitted status = %v, want %s", submitted["status"], jobs.StatusQueued)
+	}
+
+	select {
+	case job := <-got:
+		data := job.Result.(map[string]any)["data"].(map[string]any)
+		if job.Status != jobs.StatusSucceeded || data["async-user"] != "user alice" {
+			t.Fatalf("callback = %+v, want it succeeded with alice's data", job)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not received")
+	}
+
+	statusURL := submitted["status_url"].(string)
+	for _, tt := range []struct {
+		name, token string
+		want        int
+	}{
+		{"owner", alice, http.StatusOK},
+		{"another user", bob, http.StatusNotFound},
+		{"admin", admin, http.StatusOK},
+	} {
+		w := call(http.MethodGet, statusURL, tt.token, "")
+		if w.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.want, w.Body)
+		}
+		if w.Code == http.StatusOK && decode(t, w)["status"] != string(jobs.StatusSucceeded) {
+			t.Errorf("%s: job = %s, want it succeeded", tt.name, w.Body)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// errStillPending is the error of a service that hadn't answered when the best-effort deadline passed.
+var errStillPending = errors.New("still pending at the deadline")
+
+// AggregateBestEffortHandler aggregates every service under a 1s deadline like
+// AggregateHandlerWithTimeout, but when the deadline is reached it stops collecting and responds
+// at once with whatever has completed. Services still pending are listed in timed_out_services.
+//
+// The fan-out channel is buffered for every service and never closed, so fetches that finish
+// after the response has gone out still send without blocking or panicking, and then exit.
+// They are handed ctx and their ids up front, since c is reused for another request by then.
+func AggregateBestEffortHandler(c *gin.Context) {
+	defer traceAggregate(c, "best_effort")()
+	defer trackAggregate("best_effort")()
+
+	run, ok := beginAggregate(c, service.Default.All())
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+
+	resultChan := fanOut(ctx, run.services, idsFor(c, run.services, run.userID))
+	run.timer.mark("fanout")
+
+	out := newOutcomes()
+	pending := make(map[string]bool, len(run.services))
+	for name := range run.services {
+		pending[name] = true
+	}
+
+collect:
+	for range run.services {
+		select {
+		case res := <-resultChan:
+			delete(pending, res.service)
+			out.record(res, idFor(c, res.service, run.userID))
+		case <-ctx.Done():
+			break collect
+		}
+	}
+
+	// A service still pending at the deadline fails like a timed-out one, fallback included.
+	timedOut := make([]string, 0, len(pending))
+	for name := range pending {
+		timedOut = append(timedOut, name)
+		out.record(result{service: name, err: errStillPending}, idFor(c, name, run.userID))
+	}
+	sort.Strings(timedOut)
+
+	finishAggregate(c, run, out, gin.H{
+		"timed_out_services": timedOut,
+		"concurrency":        "best_effort",
+	})
+}
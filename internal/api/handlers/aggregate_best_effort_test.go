@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBestEffortReturnsBeforeASlowService(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan string, 2)
+	useServices(t, map[string]service.Fetcher{
+		"best-fast": func(ctx context.Context, userID string) (any, error) { return "fast " + userID, nil },
+		"best-slow": func(ctx context.Context, userID string) (any, error) {
+			<-release
+			finished <- userID
+			return "slow " + userID, nil
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/best-effort", AggregateBestEffortHandler)
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/best-effort"+query, nil))
+		return w
+	}
+
+	start := time.Now()
+	w := get("?user_id=u1")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("handler took %s, want it to return at its 1s deadline", elapsed)
+	}
+	body := decode(t, w)
+	if data := body["data"].(map[string]any); data["best-fast"] != "fast u1" || data["best-slow"] != nil {
+		t.Fatalf("data = %v, want only best-fast's answer", data)
+	}
+	if timedOut := body["timed_out_services"].([]any); len(timedOut) != 1 || timedOut[0] != "best-slow" {
+		t.Fatalf("timed_out_services = %v, want [best-slow]", timedOut)
+	}
+
+	// The slow fetch finishes while the router serves another request, which may reuse the
+	// first one's gin.Context; under -race any read of it from the late fetch is reported.
+	close(release)
+	get("?user_id=u2")
+	for range 2 {
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("slow fetch never finished")
+		}
+	}
+}
@@ -12,28 +12,19 @@ import (
 
 // Version 3: With Context and Timeout
 func AggregateHandlerWithTimeout(c *gin.Context) {
-	userID := c.Query("user_id")
-	if userID == "" {
-		userID = "123"
+	defer traceAggregate(c, "context_with_timeout")()
+	defer trackAggregate("context_with_timeout")()
+
+	run, ok := beginAggregate(c, service.Default.All())
+	if !ok {
+		return
 	}
+	servicesToCall := run.services
 
 	// Set overall timeout for the aggregation
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 1*time.Second)
 	defer cancel()
 
-	start := time.Now()
-	type result struct {
-		service string
-		data    any
-		err     error
-	}
-
-	servicesToCall := map[string]func(string) (any, error){
-		"user":          service.FetchUser,
-		"orders":        service.FetchOrders,
-		"notifications": service.FetchNotifications,
-	}
-
 	// create buffered channel to collect results
 	// in buffered channel, send only blocks main goroutine if buffer is full
 	// in buffered channel, receive only blocks main goroutine if buffer is empty
@@ -47,7 +38,7 @@ func AggregateHandlerWithTimeout(c *gin.Context) {
 	// Launch goroutines with context
 	for name, fetcher := range servicesToCall {
 		wg.Add(1) // Increment counter: +1 (now counter = 1, 2, 3 as we loop)
-		go func(svcName string, fn func(string) (any, error)) {
+		go func(svcName, id string, fn service.Fetcher) {
 			defer wg.Done() // Decrement counter when goroutine exits: -1
 
 			// Create a channel for the actual fetch operation
@@ -55,7 +46,9 @@ func AggregateHandlerWithTimeout(c *gin.Context) {
 			// This allows us to race between the fetch completing and the timeout
 			innerChan := make(chan result, 1)
 			go func() {
-				data, err := fn(userID)
+				// fn receives ctx, so when the deadline passes the in-flight HTTP call is aborted
+				// and this goroutine exits instead of holding a connection after we've responded.
+				data, err := tracedFetch(ctx, svcName, id, fn)
 				// Only send if channel is still open (non-blocking check)
 				select {
 				case innerChan <- result{service: svcName, data: data, err: err}:
@@ -75,15 +68,16 @@ func AggregateHandlerWithTimeout(c *gin.Context) {
 					err:     errors.New("service timeout: " + ctx.Err().Error()),
 				}
 			}
-		}(name, fetcher)
+		}(name, idFor(c, name, run.userID), fetcher)
 	}
+	run.timer.mark("fanout")
 
 	// Close resultChan when all goroutines are done
 	// This goroutine runs ONCE per request (not continuously):
 	//
 	// How wg.Wait() knows all are done:
 	// - WaitGroup maintains an internal atomic counter
-	// - We called wg.Add(1) 3 times (counter = 3)
+	// - We called wg.Add(1) once per service (e.g. 3 services → counter = 3)
 	// - Each worker calls wg.Done() when finished (counter decrements: 3→2→1→0)
 	// - wg.Wait() blocks until counter reaches 0
 	// - When counter = 0, wg.Wait() unblocks (all workers have called Done())
@@ -104,7 +98,7 @@ func AggregateHandlerWithTimeout(c *gin.Context) {
 	// IMPORTANT: This runs in the MAIN goroutine (same thread as the HTTP handler)
 	//
 	// Execution Timeline:
-	// 1. Main goroutine launches 3 worker goroutines (lines 45-75)
+	// 1. Main goroutine launches one worker goroutine per service (lines 45-75)
 	// 2. Main goroutine launches cleanup goroutine (line 93) - waits for wg.Wait()
 	// 3. Main goroutine IMMEDIATELY starts reading here (line 106) - does NOT wait for workers!
 	// 4. Main goroutine blocks on first <-resultChan (waiting for first result)
@@ -116,11 +110,10 @@ func AggregateHandlerWithTimeout(c *gin.Context) {
 	// Key Points:
 	// - resultChan is SHARED between: worker goroutines (send), main goroutine (receive), cleanup goroutine (close)
 	// - Reading happens CONCURRENTLY with workers sending (not sequentially after)
-	// - Channel is buffered (size=3), so workers can send without blocking
+	// - Channel is buffered (size=len(servicesToCall)), so workers can send without blocking
 	// - Range loop blocks on each read until data arrives or channel closes
 	// - When channel closes, range loop automatically exits (even if not all results read)
-	results := make(map[string]any)
-	errors := make([]string, 0)
+	out := newOutcomes()
 
 	// This range loop runs in the MAIN goroutine
 	// It blocks on each iteration until:
@@ -128,17 +121,10 @@ func AggregateHandlerWithTimeout(c *gin.Context) {
 	// - OR channel is closed (loop exits)
 	// - read one by one reading is blocking
 	for res := range resultChan {
-		if res.err != nil {
-			errors = append(errors, res.service+": "+res.err.Error())
-		} else {
-			results[res.service] = res.data
-		}
+		out.record(res, idFor(c, res.service, run.userID))
 	}
 
-	c.JSON(200, gin.H{
-		"data":        results,
-		"errors":      errors,
-		"duration_ms": time.Since(start).Milliseconds(),
+	finishAggregate(c, run, out, gin.H{
 		"concurrency": "context_with_timeout",
 		"timed_out":   ctx.Err() != nil,
 	})
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestAggregateServicesSelectsTheRequestedSubset(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	counting := func(name string) service.Fetcher {
+		return func(ctx context.Context, userID string) (any, error) {
+			mu.Lock()
+			calls[name]++
+			mu.Unlock()
+			return map[string]any{"userId": userID}, nil
+		}
+	}
+	useServices(t, map[string]service.Fetcher{
+		"user":          counting("user"),
+		"orders":        counting("orders"),
+		"notifications": counting("notifications"),
+	})
+
+	tests := []struct {
+		name, services string
+		want           []string
+	}{
+		{"subset", "user,orders", []string{"orders", "user"}},
+		{"duplicates called once", "orders,%20orders,user", []string{"orders", "user"}},
+		{"empty means every service", "", []string{"notifications", "orders", "user"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(calls)
+			w := serve(AggregateServicesHandler, httptest.NewRequest(http.MethodGet, "/services?user_id=u1&services="+tt.services, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			var got []string
+			for name := range decode(t, w)["data"].(map[string]any) {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) || len(calls) != len(tt.want) {
+				t.Fatalf("data for %v after calls %v, want %v", got, calls, tt.want)
+			}
+			for i, name := range tt.want {
+				if got[i] != name || calls[name] != 1 {
+					t.Fatalf("data for %v after calls %v, want %v each called once", got, calls, tt.want)
+				}
+			}
+		})
+	}
+
+	t.Run("unknown name", func(t *testing.T) {
+		w := serve(AggregateServicesHandler, httptest.NewRequest(http.MethodGet, "/services?user_id=u1&services=user,reviews", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400", w.Code)
+		}
+		if msg, _ := decode(t, w)["error"].(string); msg != `unknown service "reviews"` {
+			t.Fatalf("error = %q, want it to name reviews", msg)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// useServices points service.Default at a registry holding just fetchers until the test ends.
+func useServices(t *testing.T, fetchers map[string]service.Fetcher) {
+	t.Helper()
+	old := service.Default
+	service.Default = service.NewRegistry()
+	for name, fn := range fetchers {
+		service.Default.Register(name, fn)
+	}
+	t.Cleanup(func() { service.Default = old })
+}
+
+// serve runs req through h, mounted at req's path, and returns the response.
+func serve(h gin.HandlerFunc, req *http.Request) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Handle(req.Method, req.URL.Path, h)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decode unmarshals a JSON response body.
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body, err)
+	}
+	return body
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"sync"
-	"time"
 
 	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
 
@@ -11,51 +10,37 @@ import (
 
 // Version 1: Basic WaitGroup
 func AggregateHandler(c *gin.Context) {
-	userID := c.Query("user_id")
-	if userID == "" {
-		userID = "123"
+	defer traceAggregate(c, "waitgroup")()
+	defer trackAggregate("waitgroup")()
+
+	// Services to fetch, from the shared service registry
+	run, ok := beginAggregate(c, service.Default.All())
+	if !ok {
+		return
 	}
 
-	start := time.Now()
 	var wg sync.WaitGroup
-	var mu sync.Mutex // To safely write to the map
-	results := make(map[string]interface{})
-	errors := make([]string, 0)
-
-	// Define service to fetch
-	servicesToCall := []struct {
-		name string
-		call func(string) (interface{}, error)
-	}{
-		{"user", service.FetchUser},
-		{"orders", service.FetchOrders},
-		{"notifications", service.FetchNotifications},
-	}
+	var mu sync.Mutex // To safely record into out
+	out := newOutcomes()
 
 	// Launch goroutines for each service
-	for _, svc := range servicesToCall {
+	for name, fetcher := range run.services {
 		wg.Add(1)
-		go func(name string, fetcher func(string) (interface{}, error)) {
+		go func(name, id string, fetcher service.Fetcher) {
 			defer wg.Done()
 
-			data, err := fetcher(userID)
+			data, err := tracedFetch(c.Request.Context(), name, id, fetcher)
 			mu.Lock()
-			if err != nil {
-				errors = append(errors, name+": "+err.Error())
-			} else {
-				results[name] = data
-			}
-			mu.Unlock()
-		}(svc.name, svc.call)
+			defer mu.Unlock()
+			out.record(result{service: name, data: data, err: err}, id)
+		}(name, idFor(c, name, run.userID), fetcher)
 	}
+	run.timer.mark("fanout")
 
 	wg.Wait() // Wait for all goroutines
 
-	c.JSON(200, gin.H{
-		"success":     len(errors) == 0,
-		"data":        results,
-		"errors":      errors,
-		"duration_ms": time.Since(start).Milliseconds(),
+	finishAggregate(c, run, out, gin.H{
+		"success":     len(out.failures) == 0,
 		"concurrency": "waitgroup",
 	})
 }
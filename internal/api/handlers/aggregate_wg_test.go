@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// TestAggregateHandlerConcurrentRequests runs many concurrent requests through AggregateHandler,
+// each fanning out to many services, some failing. Run it with -race: the handler's goroutines
+// all record into one outcomes under a mutex.
+func TestAggregateHandlerConcurrentRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// The default services answer from their fakes, through their usual cache, coalescing and breaker.
+	service.SeedFakes()
+	service.SetOffline(true)
+	defer service.SetOffline(false)
+
+	var failing []string
+	for i := range 16 {
+		name := fmt.Sprintf("svc-%02d", i)
+		fail := i%4 == 0
+		if fail {
+			failing = append(failing, name+": boom")
+		}
+		service.Default.Register(name, func(ctx context.Context, userID string) (any, error) {
+			time.Sleep(time.Duration(rand.IntN(3)) * time.Millisecond)
+			if fail {
+				return nil, errors.New("boom")
+			}
+			return map[string]any{"service": name, "userId": userID}, nil
+		})
+	}
+	sort.Strings(failing)
+
+	router := gin.New()
+	router.GET("/wg", AggregateHandler)
+
+	var wg sync.WaitGroup
+	for r := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			userID := fmt.Sprint(r)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/wg?user_id="+userID, nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("request %d: status %d: %s", r, w.Code, w.Body)
+				return
+			}
+
+			var resp struct {
+				Success bool                      `json:"success"`
+				Data    map[string]map[string]any `json:"data"`
+				Errors  []string                  `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Errorf("request %d: %v", r, err)
+				return
+			}
+			if resp.Success {
+				t.Errorf("request %d: success with failing services", r)
+			}
+			if fmt.Sprint(resp.Errors) != fmt.Sprint(failing) {
+				t.Errorf("request %d: errors = %v, want %v", r, resp.Errors, failing)
+			}
+			if want := len(service.Default.Names()) - len(failing); len(resp.Data) != want {
+				t.Errorf("request %d: %d services in data, want %d", r, len(resp.Data), want)
+			}
+			for name, data := range resp.Data {
+				if name == "inventory" {
+					continue // keyed by product, not user
+				}
+				got, ok := data["userId"]
+				if !ok {
+					got = data["id"] // user
+				}
+				if got != userID {
+					t.Errorf("request %d: %s answered for user %v", r, name, got)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
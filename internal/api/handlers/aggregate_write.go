@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/status"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AggregateWriteHandler fans a write out to several services at once. The JSON body maps each
+// service to the body it is sent, e.g. {"user": {"name": "Jane"}}, and the response has the same
+// data/errors shape as the reads, with each service's answer to its write.
+//
+// Every write has updated the gateway's cache for its service before the response is sent
+// (invalidated or written through, per service.Cache.SetWritePolicy), so a read made after it
+// sees the new data. An unknown service, or one without a writer, is a 400.
+func AggregateWriteHandler(c *gin.Context) {
+	userID := requestUserID(c)
+	if !authorizeUser(c, userID) {
+		return
+	}
+
+	var bodies map[string]any
+	if err := c.ShouldBindJSON(&bodies); err != nil || len(bodies) == 0 {
+		c.JSON(400, gin.H{"error": "body must be a JSON object mapping services to what to write"})
+		return
+	}
+
+	writers := make(map[string]service.Writer, len(bodies))
+	for name := range bodies {
+		fn, ok := service.Default.GetWriter(name)
+		if !ok {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("service %q does not accept writes", name)})
+			return
+		}
+		writers[name] = fn
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	results := make(map[string]any)
+	errors := make([]string, 0)
+	failures := make(map[string]string)
+
+	for name, writer := range writers {
+		wg.Add(1)
+		go func(name, id string, writer service.Writer) {
+			defer wg.Done()
+
+			data, err := writer(c.Request.Context(), id, bodies[name])
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errors = append(errors, name+": "+err.Error())
+				failures[name] = err.Error()
+				return
+			}
+			results[name] = data
+		}(name, idFor(c, name, userID), writer)
+	}
+	wg.Wait()
+	sort.Strings(errors)
+	countOutcomes(results, failures)
+
+	c.JSON(status.FromResults(results, errors), gin.H{
+		"data":        results,
+		"errors":      errors,
+		"duration_ms": time.Since(start).Milliseconds(),
+	})
+}
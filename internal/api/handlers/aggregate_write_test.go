@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestWriteInvalidatesTheCachedRead(t *testing.T) {
+	// A downstream that answers GETs with the name last POSTed to it.
+	var mu sync.Mutex
+	name := "John Doe"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPost {
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			name = body["name"]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"name": name})
+	}))
+	defer srv.Close()
+	service.SetFetchConfig("write-profile", service.FetchConfig{BaseURL: srv.URL})
+	defer service.SetFetchConfig("write-profile", service.FetchConfig{})
+	cache := service.NewCache()
+	t.Cleanup(cache.Stop)
+	cache.SetTTL("write-profile", time.Minute)
+
+	useServices(t, map[string]service.Fetcher{
+		"write-profile": cache.Wrap("write-profile", service.HTTPFetcher("write-profile", "/profile/")),
+	})
+	service.Default.RegisterWriter("write-profile",
+		cache.WrapWriter("write-profile", service.HTTPWriter("write-profile", "/profile/")))
+
+	read := func() any {
+		t.Helper()
+		w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=u1", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("read status = %d: %s", w.Code, w.Body)
+		}
+		return decode(t, w)["data"].(map[string]any)["write-profile"].(map[string]any)["name"]
+	}
+
+	if got := read(); got != "John Doe" {
+		t.Fatalf("name before the write = %v, want John Doe", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/wg?user_id=u1", strings.NewReader(`{"write-profile": {"name": "Jane"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	if w := serve(AggregateWriteHandler, req); w.Code != http.StatusOK {
+		t.Fatalf("write status = %d: %s", w.Code, w.Body)
+	}
+
+	if got := read(); got != "Jane" {
+		t.Fatalf("name read after the write = %v, want Jane rather than the cached John Doe", got)
+	}
+}
+
+func TestWriteFailuresCarryTheirMessage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	service.SetFetchConfig("write-broken", service.FetchConfig{BaseURL: srv.URL})
+	defer service.SetFetchConfig("write-broken", service.FetchConfig{})
+
+	useServices(t, nil)
+	service.Default.RegisterWriter("write-broken", service.HTTPWriter("write-broken", "/broken/"))
+
+	req := httptest.NewRequest(http.MethodPost, "/wg?user_id=u1", strings.NewReader(`{"write-broken": {}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := serve(AggregateWriteHandler, req)
+	errs := decode(t, w)["errors"].([]any)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].(string), "write-broken: ") || errs[0] == "write-broken: " {
+		t.Fatalf("errors = %v, want one \"write-broken: <message>\"", errs)
+	}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/gin-gonic/gin"
+)
+
+// LogoutHandler returns the POST /auth/logout handler. It revokes the bearer token the request
+// was authenticated with (see tokens.Service.RevokeClaims), so it is refused from then on, and
+// answers 204. It must run after middleware.Authenticate; a token that can't be revoked is a 500.
+func LogoutHandler(svc *tokens.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := middleware.Claims(c)
+		if !ok {
+			middleware.Unauthorized(c, "missing bearer token")
+			return
+		}
+		if err := svc.RevokeClaims(claims); err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(204)
+	}
+}
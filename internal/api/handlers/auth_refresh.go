@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/Akshat-Kumar-work/pvt_go_package/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshHandler returns the POST /auth/refresh handler. It swaps the bearer token in the
+// Authorization header for a new one valid for ttl (see tokens.Refresher.RefreshToken) and
+// responds with {"token": ..., "expires_in": seconds}.
+//
+// A token not yet within the refresh window is a 400, so the client keeps using it; one that
+// doesn't verify, expired beyond the grace period or was already refreshed is a 401, and the client
+// must authenticate again.
+func RefreshHandler(refresher *tokens.Refresher, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		oldToken, ok := middleware.BearerToken(c)
+		if !ok {
+			middleware.Unauthorized(c, "missing bearer token")
+			return
+		}
+
+		token, err := refresher.RefreshToken(oldToken, ttl)
+		switch {
+		case errors.Is(err, tokens.ErrTooEarly):
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, auth.ErrExpiredToken):
+			middleware.Unauthorized(c, "token expired")
+			return
+		case errors.Is(err, tokens.ErrRevokedToken):
+			middleware.Unauthorized(c, "token revoked")
+			return
+		case err != nil:
+			middleware.Unauthorized(c, "invalid token")
+			return
+		}
+		c.JSON(200, gin.H{"token": token, "expires_in": int(ttl.Seconds())})
+	}
+}
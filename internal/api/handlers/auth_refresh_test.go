@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+)
+
+func TestRefreshHandlerSwapsATokenOnce(t *testing.T) {
+	svc := tokens.NewService([]byte("test-secret"))
+	svc.SetRevocations(tokens.NewMemoryRevocations(), time.Hour)
+	old, err := svc.CreateTokenWithClaims("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A window longer than the token's life puts it in the window straight away.
+	h := RefreshHandler(tokens.NewRefresher(svc, 2*time.Hour, 0), 30*time.Minute)
+	refresh := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return serve(h, req)
+	}
+
+	w := refresh(old)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	body := decode(t, w)
+	if body["expires_in"] != 1800.0 {
+		t.Fatalf("expires_in = %v, want 1800", body["expires_in"])
+	}
+	if claims, err := svc.VerifyToken(body["token"].(string)); err != nil || claims.Subject != "alice" {
+		t.Fatalf("refreshed token verifies as %+v, %v; want alice's", claims, err)
+	}
+
+	for token, want := range map[string]string{old: "token revoked", "": "missing bearer token"} {
+		if w := refresh(token); w.Code != http.StatusUnauthorized || !strings.Contains(w.Body.String(), want) {
+			t.Errorf("refresh with %q: status = %d, body %s; want 401 %q", token, w.Code, w.Body, want)
+		}
+	}
+}
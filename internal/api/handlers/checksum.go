@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withChecksums adds meta.checksums: a sha256 of each service's data in resp["data"],
+// so clients caching per service can spot changes without diffing whole bodies.
+// json.Marshal sorts map keys, which keeps the hash stable for equal data.
+func withChecksums(resp gin.H) {
+	data, ok := resp["data"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	checksums := make(map[string]string, len(data))
+	for name, v := range data {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(raw)
+		checksums[name] = hex.EncodeToString(sum[:])
+	}
+	meta(resp)["checksums"] = checksums
+}
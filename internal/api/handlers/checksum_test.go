@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestChecksumsTrackTheData(t *testing.T) {
+	checksums := func(data map[string]any) map[string]string {
+		resp := gin.H{"data": data}
+		withChecksums(resp)
+		return resp["meta"].(gin.H)["checksums"].(map[string]string)
+	}
+	user := func(name string) map[string]any {
+		return map[string]any{"id": "1", "name": name, "tags": []any{"a", "b"}}
+	}
+
+	first := checksums(map[string]any{"user": user("Jane"), "orders": map[string]any{"count": 2}})
+	same := checksums(map[string]any{"orders": map[string]any{"count": 2}, "user": user("Jane")})
+	changed := checksums(map[string]any{"user": user("John"), "orders": map[string]any{"count": 2}})
+
+	if first["user"] == "" || first["user"] != same["user"] || first["orders"] != same["orders"] {
+		t.Fatalf("identical data: checksums %v and %v, want equal", first, same)
+	}
+	if changed["user"] == first["user"] {
+		t.Fatalf("changed user kept checksum %s", first["user"])
+	}
+	if changed["orders"] != first["orders"] {
+		t.Fatalf("unchanged orders: checksum %s, want %s", changed["orders"], first["orders"])
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/transform"
+	"github.com/gin-gonic/gin"
+)
+
+func TestClientTypePicksTheResponseVariant(t *testing.T) {
+	mobile, err := transform.ParseTemplate("profile", `{"name": {{json .name}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := map[string]any{"name": "Jane", "bio": "a long biography", "avatar": "a large image"}
+	variants := transform.Variants{ByClient: map[string]*transform.Template{"mobile": mobile}}
+	useServices(t, map[string]service.Fetcher{
+		"profile": variants.Wrap(func(ctx context.Context, userID string) (any, error) { return full, nil }),
+	})
+
+	svc := tokens.NewService([]byte("test-secret"))
+	token := func(clientType string) string {
+		t.Helper()
+		custom := map[string]any{}
+		if clientType != "" {
+			custom["client_type"] = clientType
+		}
+		tok, err := svc.CreateTokenWithCustomClaims("alice", time.Hour, custom)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/aggregate", middleware.Authenticate(svc), AggregateServicesHandler)
+
+	slim := map[string]any{"name": "Jane"}
+	tests := []struct {
+		name, claim, header string
+		want                map[string]any
+	}{
+		{"mobile header", "", "mobile", slim},
+		{"web header", "", "web", full},
+		{"no client type", "", "", full},
+		{"mobile claim", "Mobile", "", slim},
+		{"claim wins over the header", "web", "mobile", full},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/aggregate", nil)
+			req.Header.Set("Authorization", "Bearer "+token(tt.claim))
+			if tt.header != "" {
+				req.Header.Set(middleware.ClientTypeHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", w.Code, w.Body)
+			}
+			if got := decode(t, w)["data"].(map[string]any)["profile"]; !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("profile = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withCompression replaces the data of each service named in ?compress_services=a,b
+// with {"encoding": "gzip+base64", "payload": "..."}, so clients that only need some
+// services can skip decompressing the rest. Other services stay plain.
+//
+// Entries are swapped in resp["data"], never rewritten in place: the data itself may be
+// shared with ResponseCache. Run it after withChecksums so checksums cover the plain data.
+func withCompression(c *gin.Context, resp gin.H) {
+	raw := c.Query("compress_services")
+	if raw == "" {
+		return
+	}
+	data, ok := resp["data"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		v, ok := data[name]
+		if !ok {
+			continue
+		}
+		payload, err := gzipBase64(v)
+		if err != nil {
+			continue
+		}
+		data[name] = gin.H{"encoding": "gzip+base64", "payload": payload}
+	}
+}
+
+// gzipBase64 returns v as gzipped JSON, base64-encoded.
+func gzipBase64(v any) (string, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withDedup replaces repeated objects in resp["data"] with {"$ref": "<JSON pointer>"} to the
+// first copy when the caller asked for ?dedup=true, e.g. two services embedding the same user.
+// Services and keys are walked in sorted order so the canonical copy is stable.
+//
+// Pointers are into the flat response ("#/data/orders/user"), so it does nothing for
+// ?grouped=true or ?ordered=true. Run it after withCompression: compressed services are opaque payloads and
+// nothing inside them can be referenced. The data is copied, never rewritten in place,
+// since it may be shared with ResponseCache.
+func withDedup(c *gin.Context, resp gin.H) {
+	if c.Query("dedup") != "true" || c.Query("grouped") == "true" || c.Query("ordered") == "true" {
+		return
+	}
+	data, ok := resp["data"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seen := make(map[string]string) // marshalled object -> pointer to its first copy
+	for _, name := range names {
+		data[name] = dedupValue(data[name], "#/data/"+escapePointer(name), seen)
+	}
+}
+
+// dedupValue returns a copy of v, at pointer ptr, with objects already in seen replaced by refs.
+func dedupValue(v any, ptr string, seen map[string]string) any {
+	switch t := v.(type) {
+	case map[string]any:
+		if len(t) > 0 {
+			raw, err := json.Marshal(t)
+			if err == nil {
+				if first, ok := seen[string(raw)]; ok {
+					return gin.H{"$ref": first}
+				}
+				seen[string(raw)] = ptr
+			}
+		}
+
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		out := make(map[string]any, len(t))
+		for _, k := range keys {
+			out[k] = dedupValue(t[k], ptr+"/"+escapePointer(k), seen)
+		}
+		return out
+	case []any:
+		out := make([]any, len(t))
+		for i, item := range t {
+			out[i] = dedupValue(item, ptr+"/"+strconv.Itoa(i), seen)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// escapePointer escapes a key for use as a JSON pointer token (RFC 6901).
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
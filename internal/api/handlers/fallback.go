@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withFallbacks lists the services whose data came from their fallback (see service.SetFallback)
+// as fallbacks_used, sorted. Nothing is added when no fallback was used.
+func withFallbacks(resp gin.H, used []string) {
+	if len(used) == 0 {
+		return
+	}
+	sort.Strings(used)
+	resp["fallbacks_used"] = used
+}
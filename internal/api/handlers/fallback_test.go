@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestFallbackStandsInForATimedOutService(t *testing.T) {
+	service.SetFallback("fallback-slow", func(userID string) any {
+		return map[string]any{"userId": userID, "unread": 0.0}
+	})
+	defer service.SetFallback("fallback-slow", nil)
+	useServices(t, map[string]service.Fetcher{
+		"fallback-ok": func(ctx context.Context, userID string) (any, error) { return map[string]any{"userId": userID}, nil },
+		// Never answers: only the handler's 1s timeout ends it.
+		"fallback-slow": func(ctx context.Context, userID string) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+
+	w := serve(AggregateHandlerWithTimeout, httptest.NewRequest(http.MethodGet, "/timeout?user_id=f1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	body := decode(t, w)
+	data := body["data"].(map[string]any)
+	if got := data["fallback-slow"].(map[string]any); got["unread"] != 0.0 || got["userId"] != "f1" {
+		t.Fatalf("fallback-slow data = %v, want its fallback", got)
+	}
+	if used := body["fallbacks_used"].([]any); len(used) != 1 || used[0] != "fallback-slow" {
+		t.Fatalf("fallbacks_used = %v, want [fallback-slow]", used)
+	}
+	if errs := body["errors"].([]any); len(errs) != 0 {
+		t.Fatalf("errors = %v, want the timed-out service under fallbacks_used instead", errs)
+	}
+
+	// A fallback is degraded data, not a success: it can't meet ?min_success.
+	w = serve(AggregateHandlerWithTimeout, httptest.NewRequest(http.MethodGet, "/timeout?user_id=f1&min_success=2", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("min_success=2 with one real success: status = %d, want 502", w.Code)
+	}
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/transform"
+	"github.com/gin-gonic/gin"
+)
+
+// withFields trims resp["data"] to the dotted paths in ?fields=user.name,orders.orders.total
+// (see transform.SelectFields) and reports how many paths matched nothing as skipped_fields.
+// It does nothing for ?grouped=true, whose groups are built from the untrimmed results.
+func withFields(c *gin.Context, resp gin.H) {
+	raw := c.Query("fields")
+	if raw == "" || c.Query("grouped") == "true" {
+		return
+	}
+	data, ok := resp["data"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	var paths []string
+	for _, path := range strings.Split(raw, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	selected, skipped := transform.SelectFields(data, paths)
+	resp["data"] = selected
+	resp["skipped_fields"] = len(skipped)
+}
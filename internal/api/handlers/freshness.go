@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// freshnessRank orders freshness from best to worst, for the summary.
+var freshnessRank = map[service.Freshness]int{
+	service.FreshLive:     0,
+	service.FreshCache:    1,
+	service.FreshStale:    2,
+	service.FreshFallback: 3,
+}
+
+// trackFreshness starts recording where each service's data comes from when the caller asked
+// for ?freshness=true, and returns the log (nil otherwise).
+// Call it before launching goroutines: it swaps the request's context.
+func trackFreshness(c *gin.Context) *service.FreshnessLog {
+	if c.Query("freshness") != "true" {
+		return nil
+	}
+	ctx, log := service.WithFreshnessLog(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	return log
+}
+
+// withFreshness adds meta.freshness, e.g. {"user": "cache", "orders": "live"}, for every service
+// with data in the response, and meta.freshness_summary, the least fresh of them ("live" when
+// there are none), from the log started by trackFreshness.
+func withFreshness(resp gin.H, log *service.FreshnessLog, out *outcomes) {
+	if log == nil {
+		return
+	}
+	states := make(map[string]service.Freshness, len(out.results)+len(out.fallbacks))
+	summary := service.FreshLive
+	for name := range out.results {
+		states[name] = log.Of(name)
+	}
+	for name := range out.fallbacks {
+		states[name] = service.FreshFallback
+	}
+	for _, f := range states {
+		if freshnessRank[f] > freshnessRank[summary] {
+			summary = f
+		}
+	}
+	m := meta(resp)
+	m["freshness"] = states
+	m["freshness_summary"] = summary
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestFreshnessReportsWhereEachServiceCameFrom(t *testing.T) {
+	service.ResponseCache.SetTTL("fresh-cached", time.Minute)
+	defer service.ResponseCache.SetTTL("fresh-cached", 0)
+	service.SetFallback("fresh-down", func(userID string) any { return map[string]any{"unread": 0} })
+	defer service.SetFallback("fresh-down", nil)
+
+	answer := func(ctx context.Context, userID string) (any, error) { return map[string]any{"id": userID}, nil }
+	useServices(t, map[string]service.Fetcher{
+		"fresh-cached": service.ResponseCache.Wrap("fresh-cached", answer),
+		"fresh-live":   answer,
+		"fresh-down":   func(context.Context, string) (any, error) { return nil, errors.New("boom") },
+	})
+
+	freshness := func() (map[string]any, any) {
+		t.Helper()
+		w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=fresh-1&freshness=true", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body)
+		}
+		meta := decode(t, w)["meta"].(map[string]any)
+		return meta["freshness"].(map[string]any), meta["freshness_summary"]
+	}
+
+	states, summary := freshness()
+	want := map[string]any{"fresh-cached": "live", "fresh-live": "live", "fresh-down": "fallback"}
+	for name, f := range want {
+		if states[name] != f {
+			t.Fatalf("first request: freshness = %v, want %v", states, want)
+		}
+	}
+	if summary != "fallback" {
+		t.Fatalf("first request: summary = %v, want fallback", summary)
+	}
+
+	// The cached service is served from the cache the second time.
+	states, _ = freshness()
+	if states["fresh-cached"] != "cache" || states["fresh-live"] != "live" {
+		t.Fatalf("second request: freshness = %v, want fresh-cached from the cache", states)
+	}
+
+	// Without ?freshness=true the response has none of it.
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=fresh-1", nil))
+	if meta, ok := decode(t, w)["meta"].(map[string]any); ok && meta["freshness"] != nil {
+		t.Fatalf("meta = %v without ?freshness, want no freshness", meta)
+	}
+}
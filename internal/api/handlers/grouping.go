@@ -0,0 +1,42 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// withGrouping replaces the flat data/errors fields with succeeded/failed/degraded groups
+// when the caller asked for ?grouped=true. Each group maps a service name to its outcome:
+//   - succeeded: {"data": ...} for services that answered
+//   - failed:    {"error": "..."} for services that failed with nothing to show for it
+//   - degraded:  {"data": ..., "error": "..."} for failed services served from their fallback,
+//     or from a snapshot instead
+func withGrouping(c *gin.Context, resp gin.H, out *outcomes) {
+	if c.Query("grouped") != "true" {
+		return
+	}
+
+	succeeded := gin.H{}
+	for name, data := range out.results {
+		succeeded[name] = gin.H{"data": data}
+	}
+
+	failed := gin.H{}
+	degraded := gin.H{}
+	snapshot, _ := resp["data"].(map[string]any)
+	fromSnapshot := resp["source"] == "snapshot"
+	for name, msg := range out.failures {
+		if data, ok := snapshot[name]; ok && fromSnapshot {
+			degraded[name] = gin.H{"data": data, "error": msg}
+			continue
+		}
+		if data, ok := out.fallbacks[name]; ok {
+			degraded[name] = gin.H{"data": data, "error": msg}
+			continue
+		}
+		failed[name] = gin.H{"error": msg}
+	}
+
+	delete(resp, "data")
+	delete(resp, "errors")
+	resp["succeeded"] = succeeded
+	resp["failed"] = failed
+	resp["degraded"] = degraded
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestGroupedBucketsEachOutcome(t *testing.T) {
+	service.SetFallback("group-degraded", func(userID string) any { return map[string]any{"unread": 0} })
+	defer service.SetFallback("group-degraded", nil)
+	useServices(t, map[string]service.Fetcher{
+		"group-ok":       func(ctx context.Context, userID string) (any, error) { return map[string]any{"id": userID}, nil },
+		"group-failed":   func(context.Context, string) (any, error) { return nil, errors.New("boom") },
+		"group-degraded": func(context.Context, string) (any, error) { return nil, errors.New("down") },
+	})
+
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=7&grouped=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	body := decode(t, w)
+	if _, ok := body["data"]; ok {
+		t.Fatalf("grouped response still has data: %s", w.Body)
+	}
+	group := func(name string) map[string]any {
+		g, _ := body[name].(map[string]any)
+		return g
+	}
+
+	succeeded, failed, degraded := group("succeeded"), group("failed"), group("degraded")
+	if len(succeeded) != 1 || len(failed) != 1 || len(degraded) != 1 {
+		t.Fatalf("groups = %v / %v / %v, want one service each", succeeded, failed, degraded)
+	}
+	if ok, _ := succeeded["group-ok"].(map[string]any); ok["data"].(map[string]any)["id"] != "7" {
+		t.Fatalf("succeeded = %v, want group-ok's data", succeeded)
+	}
+	if f, _ := failed["group-failed"].(map[string]any); f["error"] == nil || f["data"] != nil {
+		t.Fatalf("failed = %v, want group-failed's error only", failed)
+	}
+	if d, _ := degraded["group-degraded"].(map[string]any); d["error"] == nil || d["data"] == nil {
+		t.Fatalf("degraded = %v, want group-degraded's fallback data and error", degraded)
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// probeTimeout is how long each readiness probe may take.
+const probeTimeout = 500 * time.Millisecond
+
+// ReadyHandler returns the /health/ready handler. It probes every registered service at once
+// (see service.Probe) and reports each as up or down. The gateway is ready, a 200, unless one of
+// the critical services is down, which is a 503; non-critical services being down is only reported.
+func ReadyHandler(critical []string) gin.HandlerFunc {
+	isCritical := make(map[string]bool, len(critical))
+	for _, name := range critical {
+		isCritical[name] = true
+	}
+
+	return func(c *gin.Context) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		services := make(map[string]gin.H)
+		ready := true
+
+		for _, name := range service.Default.Names() {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+
+				latency, err := service.Probe(c.Request.Context(), name, probeTimeout)
+				detail := gin.H{
+					"status":     "up",
+					"critical":   isCritical[name],
+					"latency_ms": latency.Milliseconds(),
+				}
+				if err != nil {
+					detail["status"] = "down"
+					detail["error"] = err.Error()
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				services[name] = detail
+				if err != nil && isCritical[name] {
+					ready = false
+				}
+			}(name)
+		}
+		wg.Wait()
+
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "services": services})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready", "services": services})
+	}
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestReadyReportsEachServiceAndFailsOnCriticalOnes(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound) // any answer below 500 means reachable
+	}))
+	defer healthy.Close()
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	service.SetFetchConfig("ready-up", service.FetchConfig{BaseURL: healthy.URL})
+	defer service.SetFetchConfig("ready-up", service.FetchConfig{})
+	service.SetFetchConfig("ready-down", service.FetchConfig{BaseURL: unreachable.URL})
+	defer service.SetFetchConfig("ready-down", service.FetchConfig{})
+	unused := func(ctx context.Context, userID string) (any, error) { return nil, nil }
+	useServices(t, map[string]service.Fetcher{"ready-up": unused, "ready-down": unused})
+
+	tests := []struct {
+		name       string
+		critical   []string
+		wantCode   int
+		wantStatus string
+	}{
+		{"only the healthy service critical", []string{"ready-up"}, http.StatusOK, "ready"},
+		{"the unreachable service critical", []string{"ready-up", "ready-down"}, http.StatusServiceUnavailable, "not_ready"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(ReadyHandler(tt.critical), httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+			body := decode(t, w)
+			if body["status"] != tt.wantStatus {
+				t.Fatalf("status field = %v, want %s", body["status"], tt.wantStatus)
+			}
+			services := body["services"].(map[string]any)
+			up := services["ready-up"].(map[string]any)
+			down := services["ready-down"].(map[string]any)
+			if up["status"] != "up" || up["critical"] != true {
+				t.Fatalf("ready-up = %v, want up and critical", up)
+			}
+			if down["status"] != "down" || down["error"] == nil {
+				t.Fatalf("ready-down = %v, want down with its error", down)
+			}
+			if wantCritical := len(tt.critical) == 2; down["critical"] != wantCritical {
+				t.Fatalf("ready-down critical = %v, want %v", down["critical"], wantCritical)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	aggregateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "gateway_aggregate_duration_seconds",
+		Help:    "Total time spent serving an aggregate request, by handler.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2},
+	}, []string{"handler"})
+
+	serviceOutcomes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "gateway_service_calls_total",
+		Help: "Downstream service calls made by aggregate requests, by service and outcome (success or failure).",
+	}, []string{"service", "outcome"})
+
+	aggregatesInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_aggregate_in_flight",
+		Help: "Aggregate requests currently being served.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(aggregateDuration, serviceOutcomes, aggregatesInFlight)
+}
+
+// trackAggregate counts an aggregate request as in flight and returns the func that ends it,
+// recording its total duration under handler. Use it as defer trackAggregate("...")().
+func trackAggregate(handler string) func() {
+	start := time.Now()
+	aggregatesInFlight.Inc()
+	return func() {
+		aggregatesInFlight.Dec()
+		aggregateDuration.WithLabelValues(handler).Observe(time.Since(start).Seconds())
+	}
+}
+
+// countOutcomes adds one success per service in results and one failure per service in failures.
+func countOutcomes(results map[string]any, failures map[string]string) {
+	for name := range results {
+		serviceOutcomes.WithLabelValues(name, "success").Inc()
+	}
+	for name := range failures {
+		serviceOutcomes.WithLabelValues(name, "failure").Inc()
+	}
+}
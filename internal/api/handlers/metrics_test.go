@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestAggregateMetrics(t *testing.T) {
+	useServices(t, map[string]service.Fetcher{
+		"metrics-ok":   func(ctx context.Context, userID string) (any, error) { return map[string]any{"id": userID}, nil },
+		"metrics-down": func(context.Context, string) (any, error) { return nil, errors.New("boom") },
+	})
+	successes := serviceOutcomes.WithLabelValues("metrics-ok", "success")
+	failures := serviceOutcomes.WithLabelValues("metrics-down", "failure")
+	okBefore, downBefore := testutil.ToFloat64(successes), testutil.ToFloat64(failures)
+	observed := func() uint64 {
+		var m dto.Metric
+		if err := aggregateDuration.WithLabelValues("waitgroup").(prometheus.Histogram).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		return m.GetHistogram().GetSampleCount()
+	}
+	observedBefore := observed()
+
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=m1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	if got := testutil.ToFloat64(successes) - okBefore; got != 1 {
+		t.Fatalf("metrics-ok successes went up by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(failures) - downBefore; got != 1 {
+		t.Fatalf("metrics-down failures went up by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(aggregatesInFlight); got != 0 {
+		t.Fatalf("in flight = %v after the request, want 0", got)
+	}
+	if got := observed() - observedBefore; got != 1 {
+		t.Fatalf("%d durations observed, want 1", got)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAggregateEndpointsOffline(t *testing.T) {
+	service.SeedFakes()
+	service.SetOffline(true)
+	t.Cleanup(func() { service.SetOffline(false) })
+	// The default services, through their usual cache, coalescing and breaker, and nothing
+	// other tests registered.
+	want := []string{"inventory", "notifications", "orders", "user"}
+	fetchers := make(map[string]service.Fetcher, len(want))
+	for _, name := range want {
+		fetchers[name], _ = service.Default.Get(name)
+	}
+	useServices(t, fetchers)
+
+	endpoints := map[string]gin.HandlerFunc{"services": AggregateServicesHandler}
+	for name, h := range strategies {
+		endpoints[name] = h
+	}
+	for name, h := range endpoints {
+		t.Run(name, func(t *testing.T) {
+			w := serve(h, httptest.NewRequest(http.MethodGet, "/agg?user_id=42", nil))
+			body := decode(t, w)
+			data, _ := body["data"].(map[string]any)
+			if w.Code != http.StatusOK || len(data) != len(want) {
+				t.Fatalf("status %d, body %s; want 200 with all of %v", w.Code, w.Body, want)
+			}
+			if errs, _ := body["errors"].([]any); len(errs) > 0 {
+				t.Fatalf("errors = %v, want none offline", errs)
+			}
+			user, _ := data["user"].(map[string]any)
+			if user["id"] != "42" || user["name"] != "John Doe" {
+				t.Fatalf("user = %v, want the seeded fake for 42", user)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// withOrder, for ?ordered=true, turns resp["data"] into an array of {service, data, error}
+// objects in the order ?services= named them, since a JSON object's keys have no order a client
+// can rely on. Without ?services= the services come in sorted order. A failed service has its
+// error, and its fallback or snapshot data if it had any. ?grouped=true takes precedence.
+func withOrder(c *gin.Context, resp gin.H, out *outcomes) {
+	if c.Query("ordered") != "true" || c.Query("grouped") == "true" {
+		return
+	}
+	data, ok := resp["data"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	ordered := make([]gin.H, 0, len(out.results)+len(out.failures))
+	for _, name := range orderOf(c.Query("services"), out) {
+		entry := gin.H{"service": name, "data": data[name]}
+		if msg, failed := out.failures[name]; failed {
+			entry["error"] = msg
+		}
+		ordered = append(ordered, entry)
+	}
+	resp["data"] = ordered
+}
+
+// orderOf returns the services out has an outcome for, in the order raw (a ?services= list)
+// names them, each once, or sorted when raw is empty.
+func orderOf(raw string, out *outcomes) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		_, answered := out.results[name]
+		_, failed := out.failures[name]
+		if (answered || failed) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	if strings.TrimSpace(raw) != "" {
+		for _, name := range strings.Split(raw, ",") {
+			add(strings.TrimSpace(name))
+		}
+		return names
+	}
+	for name := range out.results {
+		add(name)
+	}
+	for name := range out.failures {
+		add(name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestOrderedResultsFollowTheRequestedOrder(t *testing.T) {
+	// Each service answers after its delay, so they finish in the reverse of the order asked for.
+	after := func(delay time.Duration, err error) service.Fetcher {
+		return func(ctx context.Context, userID string) (any, error) {
+			time.Sleep(delay)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{"userId": userID}, nil
+		}
+	}
+	useServices(t, map[string]service.Fetcher{
+		"alpha": after(30*time.Millisecond, nil),
+		"beta":  after(20*time.Millisecond, errors.New("boom")),
+		"gamma": after(0, nil),
+	})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"services=alpha,beta,gamma", []string{"alpha", "beta", "gamma"}},
+		{"services=gamma,alpha,beta", []string{"gamma", "alpha", "beta"}},
+		{"services=beta,alpha,beta", []string{"beta", "alpha"}},
+		{"", []string{"alpha", "beta", "gamma"}}, // every service, sorted
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := serve(AggregateServicesHandler, httptest.NewRequest(http.MethodGet, "/services?user_id=u1&ordered=true&"+tt.query, nil))
+			data, ok := decode(t, w)["data"].([]any)
+			if !ok {
+				t.Fatalf("data is not an array: %s", w.Body)
+			}
+			var got []string
+			for _, entry := range data {
+				entry := entry.(map[string]any)
+				name := entry["service"].(string)
+				got = append(got, name)
+				if _, failed := entry["error"]; failed != (name == "beta") {
+					t.Errorf("%s: entry = %v, want an error only for beta", name, entry)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("order = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("order = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	// Without ?ordered=true data stays keyed by service.
+	w := serve(AggregateServicesHandler, httptest.NewRequest(http.MethodGet, "/services?user_id=u1&services=gamma,alpha", nil))
+	if _, ok := decode(t, w)["data"].(map[string]any); !ok {
+		t.Fatalf("data = %s without ?ordered, want an object", w.Body)
+	}
+}
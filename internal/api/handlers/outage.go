@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	outageMu    sync.RWMutex
+	outageRatio float64 // share of open breakers above which aggregates fail fast; 0 is off
+)
+
+// SetOutageRatio makes an aggregate fail fast with a 503, without calling anything, when more than
+// ratio (0 < ratio <= 1) of the services it would call have their circuit breaker open: in a
+// widespread outage that answer is coming anyway, and fanning out only holds the client and a
+// slot for it. Zero, the default, turns the check off.
+func SetOutageRatio(ratio float64) {
+	outageMu.Lock()
+	defer outageMu.Unlock()
+	outageRatio = ratio
+}
+
+// checkOutage writes the 503 and returns false when too many of services have an open breaker
+// (see SetOutageRatio).
+func checkOutage(c *gin.Context, services map[string]service.Fetcher) bool {
+	outageMu.RLock()
+	ratio := outageRatio
+	outageMu.RUnlock()
+	if ratio <= 0 || len(services) == 0 {
+		return true
+	}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	open := service.OpenBreakers(names)
+	if float64(open)/float64(len(names)) <= ratio {
+		return true
+	}
+	c.JSON(http.StatusServiceUnavailable, gin.H{
+		"error": fmt.Sprintf("%d of %d services have their circuit breaker open", open, len(names)),
+	})
+	return false
+}
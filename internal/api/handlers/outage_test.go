@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestAggregateFailsFastWhenMostBreakersAreOpen(t *testing.T) {
+	var calls atomic.Int32
+	fetchers := make(map[string]service.Fetcher)
+	for i := range 4 {
+		name := fmt.Sprintf("outage-%d", i)
+		breaker := service.NewBreaker(1, time.Minute)
+		service.RegisterBreaker(name, breaker)
+		fetch := breaker.Wrap(func(ctx context.Context, userID string) (any, error) {
+			calls.Add(1)
+			return map[string]any{"service": name}, nil
+		})
+		if i < 3 {
+			// Trip it.
+			breaker.Wrap(func(context.Context, string) (any, error) { return nil, errors.New("boom") })(context.Background(), "1")
+		}
+		fetchers[name] = fetch
+	}
+	useServices(t, fetchers)
+	defer SetOutageRatio(0)
+
+	// 3 of 4 open is over a half: 503 without calling anything.
+	SetOutageRatio(0.5)
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=1", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusServiceUnavailable, w.Body)
+	}
+	if got, want := decode(t, w)["error"], "3 of 4 services have their circuit breaker open"; got != want {
+		t.Fatalf("error = %v, want %q", got, want)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("%d services called, want none", n)
+	}
+
+	// At or under the ratio the aggregate runs as usual.
+	SetOutageRatio(0.75)
+	w = serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status at the ratio = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("%d services called, want the 1 closed one", n)
+	}
+
+	// Zero turns the check off.
+	SetOutageRatio(0)
+	if w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=1", nil)); w.Code != http.StatusOK {
+		t.Fatalf("status with the check off = %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/transform"
+	"github.com/gin-gonic/gin"
+)
+
+// requestUserID returns the user the request aggregates for: ?user_id= if given, otherwise the
+// subject of the caller's token (see middleware.Authenticate), otherwise "123".
+func requestUserID(c *gin.Context) string {
+	if id := c.Query("user_id"); id != "" {
+		return id
+	}
+	if subject, ok := middleware.Subject(c); ok && subject != "" {
+		return subject
+	}
+	return "123"
+}
+
+// authorizeUser checks that the caller may aggregate for userID: an authenticated caller without
+// the "admin" role may only ask for their own subject, and may not give services their own ids
+// (see idFor). Otherwise a 403 is written and ok is false. Without authentication (see
+// middleware.Authenticate) anyone may ask for anyone.
+func authorizeUser(c *gin.Context, userID string) (ok bool) {
+	claims, authenticated := middleware.Claims(c)
+	if !authenticated || claims.HasRole("admin") {
+		return true
+	}
+	if userID != claims.Subject {
+		c.JSON(403, gin.H{"error": fmt.Sprintf("user_id %q is not the token's subject", userID)})
+		return false
+	}
+	for key := range c.Request.URL.Query() {
+		if strings.HasPrefix(key, "id.") {
+			c.JSON(403, gin.H{"error": key + " requires role admin"})
+			return false
+		}
+	}
+	return true
+}
+
+// idFor returns the user id the named service should be called with.
+// A caller can give a service its own id with ?id.<service>=..., e.g. ?id.orders=ord-9,
+// for downstreams that key users differently; otherwise the main user_id is used.
+//
+// Call it before launching goroutines: gin lazily caches the parsed query on first use,
+// so reading it from several goroutines at once is a data race.
+func idFor(c *gin.Context, svcName, userID string) string {
+	if id := c.Query("id." + svcName); id != "" {
+		return id
+	}
+	return userID
+}
+
+// idsFor returns the id each of servicesToCall is asked for (see idFor), so fan-out goroutines
+// don't have to read c.
+func idsFor(c *gin.Context, servicesToCall map[string]service.Fetcher, userID string) map[string]string {
+	ids := make(map[string]string, len(servicesToCall))
+	for name := range servicesToCall {
+		ids[name] = idFor(c, name, userID)
+	}
+	return ids
+}
+
+// minSuccess reads ?min_success=K, the number of services that must succeed for the
+// aggregate to count as a success. K defaults to 1, so an aggregate where nothing succeeded fails.
+// An aggregate over no services at all, e.g. when ?sample picked none, has nothing to require:
+// K is 0 and an explicit ?min_success is a 400.
+// K must be between 1 and total; otherwise a 400 is written and ok is false.
+func minSuccess(c *gin.Context, total int) (k int, ok bool) {
+	raw := c.Query("min_success")
+	if total == 0 {
+		if raw != "" {
+			c.JSON(400, gin.H{"error": "min_success needs at least one service to aggregate"})
+			return 0, false
+		}
+		return 0, true
+	}
+	if raw == "" {
+		return 1, true
+	}
+	k, err := strconv.Atoi(raw)
+	if err != nil || k < 1 || k > total {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("min_success must be between 1 and %d, got %q", total, raw)})
+		return 0, false
+	}
+	return k, true
+}
+
+// maxAge reads ?max_age=N, in seconds: cached responses stored more than N seconds ago are
+// refreshed for this request instead of served (see service.WithMaxAge). It is stored on the
+// request context, so call it before the fan-out derives its context. max_age=0 always refreshes.
+// A bad value writes a 400 and ok is false.
+func maxAge(c *gin.Context) (ok bool) {
+	raw := c.Query("max_age")
+	if raw == "" {
+		return true
+	}
+	seconds, err := strconv.Atoi(raw)
+	if err != nil || seconds < 0 {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("max_age must be a whole number of seconds, got %q", raw)})
+		return false
+	}
+	// A zero max age would mean "no limit" to the cache; a nanosecond is older than anything cached.
+	age := time.Duration(seconds) * time.Second
+	if age == 0 {
+		age = time.Nanosecond
+	}
+	c.Request = c.Request.WithContext(service.WithMaxAge(c.Request.Context(), age))
+	return true
+}
+
+// clientType records on the request context the kind of client the request comes from (see
+// middleware.ClientType), which picks the services' response template variants. Like maxAge,
+// call it before the fan-out derives its context.
+func clientType(c *gin.Context) {
+	if clientType, ok := middleware.ClientType(c); ok {
+		c.Request = c.Request.WithContext(transform.WithClientType(c.Request.Context(), clientType))
+	}
+}
+
+// aggregateStatus returns 502 and sets resp["error"] when fewer than required services succeeded.
+func aggregateStatus(resp gin.H, succeeded, required int) int {
+	if succeeded >= required {
+		return 200
+	}
+	resp["error"] = fmt.Sprintf("only %d services succeeded, min_success=%d", succeeded, required)
+	return 502
+}
+
+// sampleServices reads ?sample=P and keeps each service with probability P (0 to 1), for
+// generating varied fan-out patterns in load tests. ?seed=N makes the choice reproducible;
+// services are drawn in sorted order so the same seed always picks the same set.
+// Without ?sample every service is kept. A bad value writes a 400 and ok is false.
+func sampleServices(c *gin.Context, servicesToCall map[string]service.Fetcher) (sampled map[string]service.Fetcher, ok bool) {
+	raw := c.Query("sample")
+	if raw == "" {
+		return servicesToCall, true
+	}
+	p, err := strconv.ParseFloat(raw, 64)
+	if err != nil || p < 0 || p > 1 {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("sample must be between 0 and 1, got %q", raw)})
+		return nil, false
+	}
+
+	chance := rand.Float64
+	if rawSeed := c.Query("seed"); rawSeed != "" {
+		seed, err := strconv.ParseUint(rawSeed, 10, 64)
+		if err != nil {
+			c.JSON(400, gin.H{"error": fmt.Sprintf("seed must be a non-negative integer, got %q", rawSeed)})
+			return nil, false
+		}
+		chance = rand.New(rand.NewPCG(seed, 0)).Float64
+	}
+
+	names := make([]string, 0, len(servicesToCall))
+	for name := range servicesToCall {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sampled = make(map[string]service.Fetcher)
+	for _, name := range names {
+		if chance() < p {
+			sampled[name] = servicesToCall[name]
+		}
+	}
+	return sampled, true
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// twoOfThree registers three services, one of them failing.
+func twoOfThree(t *testing.T) {
+	ok := func(ctx context.Context, userID string) (any, error) { return userID, nil }
+	useServices(t, map[string]service.Fetcher{
+		"min-a": ok,
+		"min-b": ok,
+		"min-c": func(context.Context, string) (any, error) { return nil, errors.New("boom") },
+	})
+}
+
+func TestMinSuccess(t *testing.T) {
+	twoOfThree(t)
+	tests := []struct {
+		query   string
+		want    int
+		wantErr string
+	}{
+		{"", http.StatusOK, ""},
+		{"&min_success=2", http.StatusOK, ""},
+		{"&min_success=3", http.StatusBadGateway, "only 2 services succeeded, min_success=3"},
+		{"&min_success=0", http.StatusBadRequest, `min_success must be between 1 and 3, got "0"`},
+		{"&min_success=4", http.StatusBadRequest, `min_success must be between 1 and 3, got "4"`},
+		{"&min_success=most", http.StatusBadRequest, `min_success must be between 1 and 3, got "most"`},
+	}
+	for _, tt := range tests {
+		for name, h := range strategies {
+			w := serve(h, httptest.NewRequest(http.MethodGet, "/agg?user_id=1"+tt.query, nil))
+			if w.Code != tt.want {
+				t.Fatalf("%s %q: status %d, want %d: %s", name, tt.query, w.Code, tt.want, w.Body)
+			}
+			if got := decode(t, w)["error"]; tt.wantErr != "" && got != tt.wantErr {
+				t.Fatalf("%s %q: error %v, want %q", name, tt.query, got, tt.wantErr)
+			}
+		}
+	}
+}
+
+func TestMinSuccessKeepsTheDataWhenUnmet(t *testing.T) {
+	twoOfThree(t)
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=1&min_success=3", nil))
+	body := decode(t, w)
+	data, _ := body["data"].(map[string]any)
+	if w.Code != http.StatusBadGateway || len(data) != 2 {
+		t.Fatalf("status %d, data %v; want 502 with both answers", w.Code, body["data"])
+	}
+}
+
+func TestAuthorizeUser(t *testing.T) {
+	useServices(t, map[string]service.Fetcher{
+		"user": func(ctx context.Context, userID string) (any, error) { return userID, nil },
+	})
+	svc := tokens.NewService([]byte("test-secret"))
+	alice, err := svc.CreateTokenWithClaims("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := svc.CreateTokenWithClaims("root", []string{"admin"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/aggregate", middleware.Authenticate(svc), AggregateServicesHandler)
+
+	tests := []struct {
+		name, token, query string
+		want               int
+	}{
+		{"own subject by default", alice, "", http.StatusOK},
+		{"own subject asked for", alice, "?user_id=alice", http.StatusOK},
+		{"another user", alice, "?user_id=bob", http.StatusForbidden},
+		{"own subject with a per-service id", alice, "?id.user=bob", http.StatusForbidden},
+		{"admin for another user", admin, "?user_id=bob", http.StatusOK},
+		{"admin with a per-service id", admin, "?user_id=bob&id.user=u-9", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/aggregate"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestSampleServices(t *testing.T) {
+	services := map[string]service.Fetcher{}
+	for _, name := range []string{"sample-a", "sample-b", "sample-c", "sample-d", "sample-e", "sample-f"} {
+		services[name] = func(ctx context.Context, userID string) (any, error) { return userID, nil }
+	}
+	useServices(t, services)
+
+	sampled := func(query string) map[string]any {
+		t.Helper()
+		w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=s1&"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", query, w.Code, w.Body)
+		}
+		return decode(t, w)["data"].(map[string]any)
+	}
+
+	if got := sampled("sample=1.0"); len(got) != len(services) {
+		t.Fatalf("sample=1.0 called %d services, want all %d", len(got), len(services))
+	}
+	if got := sampled("sample=0.0"); len(got) != 0 {
+		t.Fatalf("sample=0.0 called %v, want none", got)
+	}
+
+	// The same seed picks the same set every time.
+	picked := func() string {
+		names := slices.Collect(maps.Keys(sampled("sample=0.5&seed=42")))
+		sort.Strings(names)
+		return fmt.Sprint(names)
+	}
+	first := picked()
+	for range 5 {
+		if got := picked(); got != first {
+			t.Fatalf("seed 42 picked %s, then %s", first, got)
+		}
+	}
+
+	for _, query := range []string{"sample=1.5", "sample=-0.1", "sample=half", "sample=0.5&seed=-1"} {
+		w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=s1&"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
+func TestMaxAgeRefreshesOlderCachedResponses(t *testing.T) {
+	cache := service.NewCache()
+	t.Cleanup(cache.Stop)
+	cache.SetTTL("max-age", time.Minute)
+	calls := 0
+	useServices(t, map[string]service.Fetcher{
+		"max-age": cache.Wrap("max-age", func(ctx context.Context, userID string) (any, error) {
+			calls++
+			return calls, nil
+		}),
+	})
+
+	for _, tt := range []struct {
+		query     string
+		wantCalls int
+	}{
+		{"", 1},            // first fetch, cached
+		{"&max_age=60", 1}, // younger than a minute: from the cache
+		{"&max_age=0", 2},  // nothing is young enough: refreshed
+		{"", 2},            // the refresh was cached
+	} {
+		w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=m1"+tt.query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: status = %d: %s", tt.query, w.Code, w.Body)
+		}
+		if got := decode(t, w)["data"].(map[string]any)["max-age"]; calls != tt.wantCalls || got != float64(tt.wantCalls) {
+			t.Fatalf("%q: data %v after %d calls, want %d", tt.query, got, calls, tt.wantCalls)
+		}
+	}
+
+	for _, raw := range []string{"-1", "1.5", "soon"} {
+		if w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?max_age="+raw, nil)); w.Code != http.StatusBadRequest {
+			t.Errorf("max_age=%s: status = %d, want 400", raw, w.Code)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/transform"
+	"github.com/gin-gonic/gin"
+)
+
+// pipelineFor reads ?pipeline=a,b,c, the transforms to run over the response data in order
+// (see transform.Pipeline). It returns nil when the param is absent.
+// An unknown transform name writes a 400 and ok is false.
+func pipelineFor(c *gin.Context) (pipeline transform.Func, ok bool) {
+	raw := c.Query("pipeline")
+	if raw == "" {
+		return nil, true
+	}
+
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	pipeline, err := transform.Pipeline(names...)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error(), "transforms": transform.Names()})
+		return nil, false
+	}
+	return pipeline, true
+}
+
+// withPipeline replaces resp["data"] with the pipeline's output and returns status unchanged,
+// or 500 with resp["error"] set if a transform fails. It does nothing for ?grouped=true,
+// whose groups are built from the untransformed results.
+func withPipeline(c *gin.Context, resp gin.H, pipeline transform.Func, status int) int {
+	if pipeline == nil || c.Query("grouped") == "true" {
+		return status
+	}
+	data, ok := resp["data"].(map[string]any)
+	if !ok {
+		return status
+	}
+
+	out, err := pipeline(data)
+	if err != nil {
+		resp["error"] = fmt.Sprintf("pipeline: %v", err)
+		return 500
+	}
+	resp["data"] = out
+	return status
+}
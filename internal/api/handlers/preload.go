@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	preloadMu    sync.RWMutex
+	preloadHints = make(map[string][]string) // service -> URLs the client will likely fetch next
+)
+
+// SetPreloadHints configures the URLs to hint with `Link: <url>; rel=preload; as=fetch`
+// whenever the named service's data is part of an aggregate response.
+// Calling it again for the same service replaces its hints; no URLs removes them.
+func SetPreloadHints(svcName string, urls ...string) {
+	preloadMu.Lock()
+	defer preloadMu.Unlock()
+	if len(urls) == 0 {
+		delete(preloadHints, svcName)
+		return
+	}
+	preloadHints[svcName] = urls
+}
+
+// withPreloadHints adds a Link header for every hint of every service that returned data.
+// Services are visited in sorted order so the headers are stable between requests.
+func withPreloadHints(c *gin.Context, results map[string]any) {
+	preloadMu.RLock()
+	defer preloadMu.RUnlock()
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, url := range preloadHints[name] {
+			c.Writer.Header().Add("Link", "<"+url+">; rel=preload; as=fetch")
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// trackRetries starts recording per-service retry counts for the request when the caller
+// asked for ?debug_retries=true, and returns the log (nil otherwise).
+// Call it before launching goroutines: it swaps the request's context.
+func trackRetries(c *gin.Context) *service.RetryLog {
+	if c.Query("debug_retries") != "true" {
+		return nil
+	}
+	ctx, log := service.WithRetryLog(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	return log
+}
+
+// withRetries adds meta.retries, e.g. {"orders": 2}, from the log started by trackRetries.
+// Services that needed no downstream retry are listed with 0.
+func withRetries(resp gin.H, log *service.RetryLog, servicesToCall map[string]service.Fetcher) {
+	if log == nil {
+		return
+	}
+	counts := log.Counts()
+	for name := range servicesToCall {
+		if _, ok := counts[name]; !ok {
+			counts[name] = 0
+		}
+	}
+	meta(resp)["retries"] = counts
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestDebugRetriesReportsRetryCounts(t *testing.T) {
+	service.SetRetryPolicy(time.Millisecond, time.Millisecond, 2)
+	t.Cleanup(func() { service.SetRetryPolicy(100*time.Millisecond, 2*time.Second, 2) })
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/flaky/u1" && hits.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "u1"}`))
+	}))
+	defer srv.Close()
+	for _, name := range []string{"retry-flaky", "retry-steady"} {
+		service.SetFetchConfig(name, service.FetchConfig{BaseURL: srv.URL})
+		defer service.SetFetchConfig(name, service.FetchConfig{})
+	}
+	useServices(t, map[string]service.Fetcher{
+		"retry-flaky":  service.HTTPFetcher("retry-flaky", "/flaky/"),
+		"retry-steady": service.HTTPFetcher("retry-steady", "/steady/"),
+	})
+
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=u1&debug_retries=true", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	body := decode(t, w)
+	if errs := body["errors"].([]any); len(errs) != 0 {
+		t.Fatalf("errors = %v, want the flaky service to succeed on its third attempt", errs)
+	}
+	retries := body["meta"].(map[string]any)["retries"].(map[string]any)
+	if retries["retry-flaky"] != 2.0 || retries["retry-steady"] != 0.0 {
+		t.Fatalf("meta.retries = %v, want retry-flaky 2 and retry-steady 0", retries)
+	}
+
+	// Without the flag there are no counts.
+	w = serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=u1", nil))
+	if meta, ok := decode(t, w)["meta"].(map[string]any); ok && meta["retries"] != nil {
+		t.Fatalf("meta = %v without ?debug_retries, want no retries", meta)
+	}
+}
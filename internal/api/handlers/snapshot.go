@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// withSnapshot serves the user's static snapshot when every service failed (succeeded == 0),
+// so critical pages keep rendering during a total outage.
+// The errors are left in place and the response is marked with "source": "snapshot".
+func withSnapshot(resp gin.H, userID string, succeeded int) {
+	if succeeded > 0 {
+		return
+	}
+	snap, err := service.LoadSnapshot(userID)
+	if err != nil {
+		return
+	}
+	resp["data"] = snap
+	resp["source"] = "snapshot"
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+func TestSnapshotServedWhenEveryServiceFails(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "snap-1.json"), []byte(`{"user":{"name":"Ada"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	service.SetSnapshotDir(dir)
+	defer service.SetSnapshotDir("")
+
+	down := func(context.Context, string) (any, error) { return nil, errors.New("boom") }
+	useServices(t, map[string]service.Fetcher{"snap-user": down, "snap-orders": down})
+
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=snap-1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body)
+	}
+	body := decode(t, w)
+	if body["source"] != "snapshot" {
+		t.Fatalf("source = %v, want snapshot", body["source"])
+	}
+	user, _ := body["data"].(map[string]any)["user"].(map[string]any)
+	if user["name"] != "Ada" {
+		t.Fatalf("data = %v, want the snapshot's", body["data"])
+	}
+	if errs, _ := body["errors"].([]any); len(errs) != 2 {
+		t.Fatalf("errors = %v, want both failures kept", body["errors"])
+	}
+
+	// A user without a snapshot gets the usual 502.
+	w = serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=snap-2", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status without a snapshot = %d, want %d", w.Code, http.StatusBadGateway)
+	}
+	if body := decode(t, w); body["source"] != nil {
+		t.Fatalf("source without a snapshot = %v, want none", body["source"])
+	}
+}
+
+func TestSnapshotNotServedWhenAnyServiceAnswers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "snap-1.json"), []byte(`{"user":{"name":"Ada"}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	service.SetSnapshotDir(dir)
+	defer service.SetSnapshotDir("")
+
+	useServices(t, map[string]service.Fetcher{
+		"snap-user":   func(context.Context, string) (any, error) { return nil, errors.New("boom") },
+		"snap-orders": func(context.Context, string) (any, error) { return map[string]any{"count": 1}, nil },
+	})
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=snap-1", nil))
+	if body := decode(t, w); w.Code != http.StatusOK || body["source"] != nil {
+		t.Fatalf("status = %d, source = %v, want 200 with live data", w.Code, body["source"])
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// strategies are the aggregate handlers over every service, by the fan-out strategy they report
+// as "concurrency".
+var strategies = map[string]gin.HandlerFunc{
+	"waitgroup":            AggregateHandler,
+	"channels":             AggregateChannelHandler,
+	"context_with_timeout": AggregateHandlerWithTimeout,
+	"best_effort":          AggregateBestEffortHandler,
+}
+
+// WithStrategyOverride returns h, except that a request whose X-Feature-Override set a strategy
+// (see middleware.FeatureOverrides) is handled by that strategy's handler instead. An unknown
+// strategy gets a 400.
+func WithStrategyOverride(h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, ok := middleware.FeatureOverride(c, "strategy")
+		if !ok {
+			h(c)
+			return
+		}
+		strategy, ok := strategies[name]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown strategy " + name})
+			return
+		}
+		strategy(c)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestStrategyOverride(t *testing.T) {
+	useServices(t, map[string]service.Fetcher{
+		"strategy-svc": func(ctx context.Context, userID string) (any, error) { return userID, nil },
+	})
+	svc := tokens.NewService([]byte("test-secret"))
+	admin, _ := svc.CreateTokenWithClaims("alice", []string{"admin"}, time.Hour)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/wg", middleware.Authenticate(svc), middleware.FeatureOverrides("admin"), WithStrategyOverride(AggregateHandler))
+	send := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/wg?user_id=1", nil)
+		req.Header.Set("Authorization", "Bearer "+admin)
+		req.Header.Set(middleware.FeatureOverrideHeader, header)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct{ header, want string }{
+		{"", "waitgroup"},
+		{"strategy=channels", "channels"},
+		{"strategy=best_effort", "best_effort"},
+		{"strategy=context_with_timeout", "context_with_timeout"},
+	}
+	for _, tt := range tests {
+		w := send(tt.header)
+		if got := decode(t, w)["concurrency"]; w.Code != http.StatusOK || got != tt.want {
+			t.Fatalf("X-Feature-Override %q: status %d, concurrency %v; want 200, %s", tt.header, w.Code, got, tt.want)
+		}
+	}
+	if w := send("strategy=carrier-pigeon"); w.Code != http.StatusBadRequest {
+		t.Fatalf("unknown strategy: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
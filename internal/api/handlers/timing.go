@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/middleware"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// phaseTimer measures consecutive phases of a handler.
+// Each mark records the time since the previous mark (or since creation) under the given name.
+type phaseTimer struct {
+	last    time.Time
+	phases  map[string]float64   // phase name -> milliseconds
+	lookups *service.LookupTimer // the request's cache lookups; nil unless ?debug_timing=true
+}
+
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{last: time.Now(), phases: make(map[string]float64)}
+}
+
+// startPhases returns the handler's phase timer, started now. For ?debug_timing=true it also
+// records the middleware phase, up to now, and starts timing the request's cache lookups.
+// Call it before launching goroutines: it swaps the request's context.
+func startPhases(c *gin.Context) *phaseTimer {
+	timer := newPhaseTimer()
+	if c.Query("debug_timing") != "true" {
+		return timer
+	}
+	if start, ok := middleware.RequestStart(c); ok {
+		timer.phases["middleware"] = milliseconds(timer.last.Sub(start))
+	}
+	ctx, lookups := service.WithLookupTimer(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	timer.lookups = lookups
+	return timer
+}
+
+// mark closes the current phase under name and starts the next one.
+func (p *phaseTimer) mark(name string) {
+	now := time.Now()
+	p.phases[name] += milliseconds(now.Sub(p.last))
+	p.last = now
+}
+
+// withPhases adds meta.phases to resp when the caller asked for ?debug_timing=true.
+// Phases are:
+//   - middleware:   from the gateway receiving the request to the handler starting,
+//     admission queue wait (see withQueueWait) included
+//   - fanout:       reading the request's parameters and launching one goroutine per service
+//   - collect:      waiting for and reading results off the channel / WaitGroup
+//   - assembly:     building the response body, every response feature included
+//   - cache_lookup: time spent looking up the response cache, summed over services. It is spent
+//     inside fanout and collect, so it isn't part of their sum
+//
+// middleware, fanout, collect and assembly add up to the request's time in the gateway, less
+// encoding the response. Run it last, so assembly covers everything before it.
+func withPhases(c *gin.Context, resp gin.H, timer *phaseTimer) {
+	if c.Query("debug_timing") != "true" {
+		return
+	}
+	timer.mark("assembly")
+	if timer.lookups != nil {
+		timer.phases["cache_lookup"] = milliseconds(timer.lookups.Total())
+	}
+	meta(resp)["phases"] = timer.phases
+}
+
+// milliseconds returns d in fractional milliseconds. Cache lookups take well under a
+// microsecond, so nothing is rounded away.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// withQueueWait reports how long the request waited for admission (see middleware.Admission)
+// as meta.queue_wait_ms and as a "queue" Server-Timing entry, so clients can account for it
+// in their own latency budgets.
+func withQueueWait(c *gin.Context, resp gin.H) {
+	wait, ok := middleware.QueueWait(c)
+	if !ok {
+		return
+	}
+	ms := milliseconds(wait)
+	meta(resp)["queue_wait_ms"] = ms
+	c.Writer.Header().Add("Server-Timing", fmt.Sprintf("queue;dur=%.3f", ms))
+}
+
+// meta returns resp["meta"], creating it on first use.
+func meta(resp gin.H) gin.H {
+	m, ok := resp["meta"].(gin.H)
+	if !ok {
+		m = gin.H{}
+		resp["meta"] = m
+	}
+	return m
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/api/handlers")
+
+// traceAggregate starts the request's parent span, named after handler, and returns the func
+// that ends it. Use it as defer traceAggregate(c, "...")().
+// Call it before launching goroutines: it swaps the request's context.
+func traceAggregate(c *gin.Context, handler string) func() {
+	ctx, span := tracer.Start(c.Request.Context(), "aggregate "+handler)
+	c.Request = c.Request.WithContext(ctx)
+	return func() { span.End() }
+}
+
+// tracedFetch calls fetcher under a child span for the named service. A failed call, including
+// one cut off by the request's timeout, marks the span with an error status.
+func tracedFetch(ctx context.Context, name, userID string, fetcher service.Fetcher) (any, error) {
+	ctx, span := tracer.Start(ctx, "fetch "+name, trace.WithAttributes(attribute.String("service", name)))
+	defer span.End()
+
+	data, err := fetcher(ctx, userID)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, service.Category(err))
+	}
+	return data, err
+}
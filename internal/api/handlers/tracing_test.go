@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var (
+	spansOnce sync.Once
+	spans     = tracetest.NewInMemoryExporter()
+)
+
+// recordSpans makes the global tracer provider export to spans, once for the whole package:
+// tracer was created against the global provider and follows it from then on.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	spansOnce.Do(func() {
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
+	})
+	spans.Reset()
+	t.Cleanup(spans.Reset)
+	return spans
+}
+
+func TestAggregateSpans(t *testing.T) {
+	exporter := recordSpans(t)
+	useServices(t, map[string]service.Fetcher{
+		"trace-ok":   func(ctx context.Context, userID string) (any, error) { return userID, nil },
+		"trace-down": func(context.Context, string) (any, error) { return nil, errors.New("boom") },
+	})
+
+	w := serve(AggregateHandler, httptest.NewRequest(http.MethodGet, "/wg?user_id=t1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+
+	byName := make(map[string]tracetest.SpanStub)
+	for _, s := range exporter.GetSpans() {
+		if _, dup := byName[s.Name]; dup {
+			t.Fatalf("two %q spans", s.Name)
+		}
+		byName[s.Name] = s
+	}
+	parent, ok := byName["aggregate waitgroup"]
+	if !ok || len(byName) != 3 {
+		t.Fatalf("spans = %v, want the aggregate and one per service", byName)
+	}
+
+	tests := []struct {
+		service string
+		status  codes.Code
+	}{
+		{"trace-ok", codes.Unset},
+		{"trace-down", codes.Error},
+	}
+	for _, tt := range tests {
+		s, ok := byName["fetch "+tt.service]
+		if !ok {
+			t.Fatalf("no span for %s", tt.service)
+		}
+		if s.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Fatalf("%s span isn't a child of the aggregate span", tt.service)
+		}
+		var attr string
+		for _, kv := range s.Attributes {
+			if kv.Key == "service" {
+				attr = kv.Value.AsString()
+			}
+		}
+		if attr != tt.service {
+			t.Fatalf("%s span: service attribute = %q", tt.service, attr)
+		}
+		if s.Status.Code != tt.status {
+			t.Fatalf("%s span: status = %v, want %v", tt.service, s.Status.Code, tt.status)
+		}
+	}
+	if got := byName["fetch trace-down"].Status.Description; got != "upstream" {
+		t.Fatalf("failed span's status description = %q, want its category", got)
+	}
+}
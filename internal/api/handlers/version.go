@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/version"
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHandler returns the /version handler: the build's version, commit and build time (see
+// package version), the Go runtime it runs on, and which optional features are switched on.
+func VersionHandler(features map[string]bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		info := version.Get()
+		c.JSON(200, gin.H{
+			"version":    info.Version,
+			"commit":     info.Commit,
+			"build_time": info.BuildTime,
+			"go_version": info.GoVersion,
+			"features":   features,
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/version"
+)
+
+func TestVersionReportsBuildInfoAndFeatures(t *testing.T) {
+	old := [3]string{version.Version, version.Commit, version.BuildTime}
+	version.Version, version.Commit, version.BuildTime = "v1.2.0", "abc123", "2026-01-01T12:00:00Z"
+	defer func() { version.Version, version.Commit, version.BuildTime = old[0], old[1], old[2] }()
+
+	w := serve(VersionHandler(map[string]bool{"tracing": true, "offline": false}), httptest.NewRequest(http.MethodGet, "/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	body := decode(t, w)
+	for field, want := range map[string]string{
+		"version":    "v1.2.0",
+		"commit":     "abc123",
+		"build_time": "2026-01-01T12:00:00Z",
+		"go_version": runtime.Version(),
+	} {
+		if body[field] != want {
+			t.Errorf("%s = %v, want %s", field, body[field], want)
+		}
+	}
+	features, _ := body["features"].(map[string]any)
+	if features["tracing"] != true || features["offline"] != false || len(features) != 2 {
+		t.Errorf("features = %v, want tracing on and offline off", body["features"])
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+var active atomic.Int64
+
+// CountActive tracks how many requests are being served; see ActiveRequests.
+func CountActive() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		active.Add(1)
+		defer active.Add(-1)
+		c.Next()
+	}
+}
+
+// ActiveRequests returns the number of requests currently inside CountActive.
+func ActiveRequests() int64 {
+	return active.Load()
+}
@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrShed is returned when a request is dropped from a full admission queue.
+var ErrShed = errors.New("request shed: admission queue full")
+
+// Priorities assigned by TokenPriority. Higher is admitted first.
+const (
+	PriorityAnonymous     = 0
+	PriorityAuthenticated = 1
+	PriorityPremium       = 2
+)
+
+// PriorityFunc derives a request's admission priority. Higher values are admitted first.
+type PriorityFunc func(c *gin.Context) int
+
+// TokenPriority ranks requests by the token Authenticate verified for them: unauthenticated
+// requests lowest, authenticated ones above them, and ones whose token has the "premium" tier
+// claim highest. Only verified claims count, as anything else is up to the client to say, so the
+// admission middleware must run after Authenticate.
+func TokenPriority(c *gin.Context) int {
+	claims, ok := Claims(c)
+	switch {
+	case !ok:
+		return PriorityAnonymous
+	case claims.Tier == "premium":
+		return PriorityPremium
+	default:
+		return PriorityAuthenticated
+	}
+}
+
+// Admission lets at most capacity requests run at once. Requests over that wait in a queue
+// ordered by priority (FIFO within a priority) and are admitted as running ones finish.
+//
+// When the queue is full a new request displaces the lowest-priority, most recently queued
+// waiter if it outranks it; otherwise the new request itself is shed.
+type Admission struct {
+	mu       sync.Mutex
+	capacity int
+	maxQueue int
+	priority PriorityFunc
+	active   int
+	queue    waitQueue
+	seq      uint64
+}
+
+// NewAdmission returns an admission queue running up to capacity requests with up to
+// maxQueue waiting. A nil priority ranks every request the same.
+func NewAdmission(capacity, maxQueue int, priority PriorityFunc) *Admission {
+	if capacity < 1 {
+		capacity = 1
+	}
+	if maxQueue < 0 {
+		maxQueue = 0
+	}
+	if priority == nil {
+		priority = func(*gin.Context) int { return 0 }
+	}
+	return &Admission{capacity: capacity, maxQueue: maxQueue, priority: priority}
+}
+
+// queueWaitKey is the gin context key holding how long the request waited for admission.
+const queueWaitKey = "admission.queue_wait"
+
+// Middleware admits each request through the queue before running the rest of the chain.
+// Shed requests, and ones whose client gives up while queued, get a 503.
+// The time spent waiting is available to handlers through QueueWait, and the request's priority
+// carries on to its downstream calls (see service.WithPriority).
+func (a *Admission) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		priority := a.priority(c)
+		if err := a.Acquire(c.Request.Context(), priority); err != nil {
+			c.AbortWithStatusJSON(503, gin.H{"error": err.Error()})
+			return
+		}
+		defer a.Release()
+		c.Set(queueWaitKey, time.Since(start))
+		c.Request = c.Request.WithContext(service.WithPriority(c.Request.Context(), priority))
+		c.Next()
+	}
+}
+
+// QueueWait returns how long the request waited in an admission queue before being
+// admitted. ok is false if it didn't go through one.
+func QueueWait(c *gin.Context) (wait time.Duration, ok bool) {
+	v, ok := c.Get(queueWaitKey)
+	if !ok {
+		return 0, false
+	}
+	wait, ok = v.(time.Duration)
+	return wait, ok
+}
+
+// Acquire blocks until the request is admitted, shed, or ctx is done.
+// Every nil return must be paired with a Release.
+func (a *Admission) Acquire(ctx context.Context, priority int) error {
+	a.mu.Lock()
+	if a.active < a.capacity && a.queue.Len() == 0 {
+		a.active++
+		a.mu.Unlock()
+		return nil
+	}
+
+	if a.queue.Len() >= a.maxQueue {
+		lowest := a.queue.lowest()
+		if lowest == nil || lowest.priority >= priority {
+			a.mu.Unlock()
+			return ErrShed
+		}
+		heap.Remove(&a.queue, lowest.index)
+		lowest.shed = true
+		close(lowest.ready)
+	}
+
+	a.seq++
+	w := &waiter{priority: priority, seq: a.seq, ready: make(chan struct{})}
+	heap.Push(&a.queue, w)
+	a.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		if w.shed {
+			return ErrShed
+		}
+		return nil
+	case <-ctx.Done():
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Admitted or shed while we were giving up.
+			if !w.shed {
+				a.releaseLocked()
+			}
+		default:
+			heap.Remove(&a.queue, w.index)
+		}
+		return ctx.Err()
+	}
+}
+
+// Release frees the slot taken by a successful Acquire, handing it straight to the
+// highest-priority waiter if there is one.
+func (a *Admission) Release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.releaseLocked()
+}
+
+// releaseLocked is Release without locking. Caller must hold a.mu.
+func (a *Admission) releaseLocked() {
+	if a.queue.Len() == 0 {
+		a.active--
+		return
+	}
+	w := heap.Pop(&a.queue).(*waiter)
+	close(w.ready)
+}
+
+// waiter is one queued request. ready is closed when it is admitted or shed.
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	shed     bool
+	index    int
+}
+
+// waitQueue is a max-heap of waiters by priority, then by arrival.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return w
+}
+
+// lowest returns the waiter that would be admitted last, or nil if the queue is empty.
+func (q waitQueue) lowest() *waiter {
+	var low *waiter
+	for _, w := range q {
+		if low == nil || w.priority < low.priority || (w.priority == low.priority && w.seq > low.seq) {
+			low = w
+		}
+	}
+	return low
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdmissionAdmitsHigherPriorityFirst(t *testing.T) {
+	a := NewAdmission(1, 10, nil)
+	if err := a.Acquire(context.Background(), PriorityAnonymous); err != nil {
+		t.Fatal(err)
+	}
+
+	// Queue a waiter per priority, lowest first, so arrival order alone would get it wrong.
+	admitted := make(chan int, 3)
+	for _, priority := range []int{PriorityAnonymous, PriorityAuthenticated, PriorityPremium} {
+		go func() {
+			if err := a.Acquire(context.Background(), priority); err != nil {
+				t.Error(err)
+				return
+			}
+			admitted <- priority
+		}()
+		waitForQueue(t, a, priority+1)
+	}
+
+	for _, want := range []int{PriorityPremium, PriorityAuthenticated, PriorityAnonymous} {
+		a.Release()
+		if got := <-admitted; got != want {
+			t.Fatalf("admitted priority %d, want %d", got, want)
+		}
+	}
+	a.Release()
+}
+
+func TestAdmissionShedsLowestPriorityWhenFull(t *testing.T) {
+	a := NewAdmission(1, 1, nil)
+	if err := a.Acquire(context.Background(), PriorityAnonymous); err != nil {
+		t.Fatal(err)
+	}
+
+	shed := make(chan error, 1)
+	go func() { shed <- a.Acquire(context.Background(), PriorityAnonymous) }()
+	waitForQueue(t, a, 1)
+
+	admitted := make(chan error, 1)
+	go func() { admitted <- a.Acquire(context.Background(), PriorityPremium) }()
+	if err := <-shed; !errors.Is(err, ErrShed) {
+		t.Fatalf("displaced waiter got %v, want %v", err, ErrShed)
+	}
+
+	// With the queue full of a premium waiter, a new anonymous request is shed itself.
+	if err := a.Acquire(context.Background(), PriorityAnonymous); !errors.Is(err, ErrShed) {
+		t.Fatalf("Acquire() = %v, want %v", err, ErrShed)
+	}
+	a.Release()
+	if err := <-admitted; err != nil {
+		t.Fatal(err)
+	}
+	a.Release()
+}
+
+func TestTokenPriorityUsesVerifiedClaimsOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name   string
+		claims *tokens.Claims
+		header string
+		want   int
+	}{
+		{"unauthenticated", nil, "", PriorityAnonymous},
+		{"unauthenticated claiming premium", nil, "premium", PriorityAnonymous},
+		{"authenticated", &tokens.Claims{}, "premium", PriorityAuthenticated},
+		{"premium tier claim", &tokens.Claims{Tier: "premium"}, "", PriorityPremium},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/", nil)
+			c.Request.Header.Set("Authorization", "Bearer whatever")
+			c.Request.Header.Set("X-User-Tier", tt.header)
+			if tt.claims != nil {
+				c.Set(claimsKey, tt.claims)
+			}
+			if got := TokenPriority(c); got != tt.want {
+				t.Fatalf("TokenPriority() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// waitForQueue waits until a has n requests queued.
+func waitForQueue(t *testing.T, a *Admission, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		a.mu.Lock()
+		queued := a.queue.Len()
+		a.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d requests queued, want %d", queued, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,205 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/gin-gonic/gin"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// newAuthRouter returns a router authenticating with svc, then running extra, in front of a
+// handler answering 200 on every route.
+func newAuthRouter(svc *tokens.Service, extra ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	group := router.Group("/", append([]gin.HandlerFunc{Authenticate(svc)}, extra...)...)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	group.GET("/api/aggregate", ok)
+	group.POST("/api/aggregate", ok)
+	group.GET("/api/aggregate/async/:id", ok)
+	group.GET("/api/aggregate/wg", ok)
+	return router
+}
+
+// call sends method path to router with token as its bearer token, if not empty.
+func call(router *gin.Engine, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// tokenWith returns a token for subject issued by svc with custom claims.
+func tokenWith(t *testing.T, svc *tokens.Service, subject string, custom map[string]any) string {
+	t.Helper()
+	token, err := svc.CreateTokenWithCustomClaims(subject, time.Hour, custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestAuthenticate(t *testing.T) {
+	svc := tokens.NewService([]byte("test-secret"))
+	valid, err := svc.CreateTokenWithClaims("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The service only issues live tokens, so the expired one is signed by hand.
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tokens.Claims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   "alice",
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute)),
+	}}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged, err := tokens.NewService([]byte("other-secret")).CreateTokenWithClaims("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/aggregate", Authenticate(svc), func(c *gin.Context) {
+		subject, _ := Subject(c)
+		c.String(http.StatusOK, subject)
+	})
+
+	tests := []struct {
+		name, header string
+		want         int
+		wantBody     string
+	}{
+		{"valid token", "Bearer " + valid, http.StatusOK, "alice"},
+		{"missing header", "", http.StatusUnauthorized, "missing bearer token"},
+		{"another scheme", "Basic " + valid, http.StatusUnauthorized, "missing bearer token"},
+		{"no token after the scheme", "Bearer ", http.StatusUnauthorized, "missing bearer token"},
+		{"malformed token", "Bearer not.a.jwt", http.StatusUnauthorized, "invalid token"},
+		{"wrong signing secret", "Bearer " + forged, http.StatusUnauthorized, "invalid token"},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized, "token expired"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/aggregate", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want || !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Fatalf("status = %d, body %s; want %d with %q", w.Code, w.Body, tt.want, tt.wantBody)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("401 without a WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	svc := tokens.NewService([]byte("test-secret"))
+	router := newAuthRouter(svc, RequireRole("admin"))
+	token := func(roles ...string) string {
+		t.Helper()
+		tok, err := svc.CreateTokenWithClaims("alice", roles, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tok
+	}
+
+	tests := []struct {
+		name, token string
+		want        int
+	}{
+		{"admin", token("reader", "admin"), http.StatusOK},
+		{"other roles only", token("reader"), http.StatusForbidden},
+		{"no roles claim", token(), http.StatusForbidden},
+		{"role name differs in case", token("Admin"), http.StatusForbidden},
+		{"unauthenticated", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := call(router, http.MethodGet, "/api/aggregate", tt.token)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusForbidden && !strings.Contains(w.Body.String(), "requires role admin") {
+				t.Fatalf("403 body = %s, want it to name the role", w.Body)
+			}
+		})
+	}
+}
+
+func TestRequireRouteScopes(t *testing.T) {
+	svc := tokens.NewService([]byte("test-secret"))
+	router := newAuthRouter(svc, RequireRouteScopes(map[string][]string{
+		"POST /api/aggregate":      {"aggregate:write"},
+		"/api/aggregate":           {"aggregate:read"},
+		"/api/aggregate/async/:id": {"aggregate:read", "jobs:read"},
+	}))
+	reader := tokenWith(t, svc, "alice", map[string]any{"scopes": []string{"aggregate:read"}})
+	writer := tokenWith(t, svc, "bob", map[string]any{"scopes": []string{"aggregate:write"}})
+	both := tokenWith(t, svc, "carol", map[string]any{"scopes": []string{"aggregate:read", "jobs:read"}})
+	none := tokenWith(t, svc, "dave", nil)
+
+	tests := []struct {
+		name, method, path, token string
+		want                      int
+	}{
+		{"read scope on a read route", http.MethodGet, "/api/aggregate", reader, http.StatusOK},
+		{"write scope on a read route", http.MethodGet, "/api/aggregate", writer, http.StatusForbidden},
+		{"method-specific entry wins", http.MethodPost, "/api/aggregate", writer, http.StatusOK},
+		{"read scope on the write route", http.MethodPost, "/api/aggregate", reader, http.StatusForbidden},
+		{"every listed scope needed", http.MethodGet, "/api/aggregate/async/1", reader, http.StatusForbidden},
+		{"every listed scope held", http.MethodGet, "/api/aggregate/async/1", both, http.StatusOK},
+		{"unlisted route needs none", http.MethodGet, "/api/aggregate/wg", none, http.StatusOK},
+		{"no token is still a 401", http.MethodGet, "/api/aggregate", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := call(router, tt.method, tt.path, tt.token); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestRefreshNearExpiry(t *testing.T) {
+	svc := tokens.NewService([]byte("test-secret"))
+	svc.SetRevocations(tokens.NewMemoryRevocations(), time.Hour)
+	router := newAuthRouter(svc, RefreshNearExpiry(svc, 5*time.Minute, time.Hour))
+
+	expiring, err := svc.CreateTokenWithClaims("alice", []string{"admin"}, 2*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := call(router, http.MethodGet, "/api/aggregate", expiring)
+	fresh := w.Header().Get(RefreshedTokenHeader)
+	if w.Code != http.StatusOK || fresh == "" {
+		t.Fatalf("status %d, %s %q; want 200 with a refreshed token", w.Code, RefreshedTokenHeader, fresh)
+	}
+	claims, err := svc.VerifyToken(fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "alice" || !claims.HasRole("admin") || time.Until(claims.ExpiresAt.Time) < 59*time.Minute {
+		t.Fatalf("refreshed claims = %+v, want alice's, expiring in an hour", claims)
+	}
+
+	// The fresh token is far from expiry, so isn't refreshed again; the old one is spent.
+	if w := call(router, http.MethodGet, "/api/aggregate", fresh); w.Header().Get(RefreshedTokenHeader) != "" {
+		t.Fatalf("a fresh token was refreshed")
+	}
+	if w := call(router, http.MethodGet, "/api/aggregate", expiring); w.Code != http.StatusUnauthorized {
+		t.Fatalf("old token: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ForwardHeaders stores the request's whitelisted headers (see service.SetForwardHeaders)
+// in its context so fetchers copy them onto downstream calls. Headers past maxBytes in all
+// are dropped (see service.TrimForwardedHeaders) and logged to logger, so a client sending huge
+// headers can't balloon every downstream call.
+func ForwardHeaders(maxBytes int, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := service.WithForwardedHeaders(c.Request.Context(), c.Request.Header)
+		ctx, dropped := service.TrimForwardedHeaders(ctx, maxBytes)
+		if len(dropped) > 0 {
+			logger.WarnContext(ctx, "forwarded headers over the size limit dropped",
+				"request_id", service.RequestID(ctx),
+				"dropped", dropped,
+				"max_bytes", maxBytes,
+			)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Gzip compresses response bodies of at least minSize bytes for clients that accept gzip
+// (Accept-Encoding). Smaller bodies aren't worth the CPU and are sent as they are, as are
+// responses a handler has already encoded (it set Content-Encoding, e.g. /metrics).
+//
+// The body is buffered until the handler returns, which suits the gateway's JSON responses
+// but not streaming ones. Any response big enough to compress carries Vary: Accept-Encoding.
+// If the handler panics nothing is sent and the panic carries on up to Recovery.
+func Gzip(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		completed := false
+		defer func() {
+			c.Writer = w.ResponseWriter
+			// A panicking handler's partial body is dropped unsent, so Recovery can still write a 500.
+			if completed {
+				w.flush(acceptsGzip(c.GetHeader("Accept-Encoding")), minSize)
+			}
+		}()
+		c.Next()
+		completed = true
+	}
+}
+
+// bufferedWriter holds the response body back so Gzip can decide how to send it.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// flush sends the buffered body, gzipped if gzipOK and it is at least minSize bytes and not already encoded.
+func (w *bufferedWriter) flush(gzipOK bool, minSize int) {
+	header := w.Header()
+	if w.body.Len() < minSize || header.Get("Content-Encoding") != "" {
+		w.ResponseWriter.Write(w.body.Bytes())
+		return
+	}
+
+	header.Add("Vary", "Accept-Encoding")
+	if !gzipOK {
+		w.ResponseWriter.Write(w.body.Bytes())
+		return
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(w.body.Bytes())
+	zw.Close()
+
+	header.Set("Content-Encoding", "gzip")
+	header.Set("Content-Length", strconv.Itoa(compressed.Len()))
+	w.ResponseWriter.Write(compressed.Bytes())
+}
+
+// acceptsGzip reports whether an Accept-Encoding header value allows gzip, explicitly or
+// through "*", without ruling it out with q=0.
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		q, _ := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if q != "" {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
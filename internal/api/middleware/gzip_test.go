@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newGzipRouter serves body at /body through Gzip(1024).
+func newGzipRouter(body string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Gzip(1024))
+	router.GET("/body", func(c *gin.Context) {
+		c.String(http.StatusOK, body)
+	})
+	return router
+}
+
+func getBody(router *gin.Engine, acceptEncoding string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/body", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGzipCompressesLargeResponses(t *testing.T) {
+	body := strings.Repeat(`{"id":"123","name":"test"}`, 100)
+	w := getBody(newGzipRouter(body), "br, gzip;q=0.8")
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+		t.Fatalf("Vary = %q, want Accept-Encoding", got)
+	}
+	if w.Body.Len() >= len(body) {
+		t.Fatalf("compressed body is %d bytes, original %d", w.Body.Len(), len(body))
+	}
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != body {
+		t.Fatal("decompressed body differs from the original")
+	}
+}
+
+func TestGzipLeavesOtherResponsesPlain(t *testing.T) {
+	large := strings.Repeat("x", 2048)
+	for _, tc := range []struct {
+		name, body, acceptEncoding, vary string
+	}{
+		{"no Accept-Encoding", large, "", "Accept-Encoding"},
+		{"gzip refused", large, "gzip;q=0, br", "Accept-Encoding"},
+		{"small body", "small", "gzip", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := getBody(newGzipRouter(tc.body), tc.acceptEncoding)
+			if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Fatalf("Content-Encoding = %q, want none", got)
+			}
+			if got := w.Header().Get("Vary"); got != tc.vary {
+				t.Fatalf("Vary = %q, want %q", got, tc.vary)
+			}
+			if w.Body.String() != tc.body {
+				t.Fatal("body differs from the original")
+			}
+		})
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"GZIP":              true,
+		"deflate, gzip":     true,
+		"*":                 true,
+		"gzip;q=0":          false,
+		"gzip; q=0.5":       true,
+		"br, deflate":       false,
+		"identity, *;q=0.1": true,
+	} {
+		if got := acceptsGzip(header); got != want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestGzipLetsRecoveryAnswerAPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery(), Gzip(1024))
+	router.GET("/body", func(c *gin.Context) {
+		c.String(http.StatusOK, strings.Repeat("partial ", 1000))
+		panic("boom")
+	})
+
+	w := getBody(router, "gzip")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("sent %d bytes with Content-Encoding %q, want the partial body dropped",
+			w.Body.Len(), w.Header().Get("Content-Encoding"))
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// LoopGuard rejects requests that have already passed through gateways more than maxHops times
+// (see service.HopsHeader) with a 508 Loop Detected, cutting off a request that a misconfigured
+// downstream keeps sending back to the gateway. A malformed hop count is a 400.
+// The count is stored in the request context so fetchers send it on, incremented.
+func LoopGuard(maxHops int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		hops := 0
+		if raw := c.GetHeader(service.HopsHeader); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s header %q", service.HopsHeader, raw)})
+				return
+			}
+			hops = n
+		}
+		if hops > maxHops {
+			c.AbortWithStatusJSON(http.StatusLoopDetected, gin.H{
+				"error": fmt.Sprintf("request loop detected: %d gateway hops, limit is %d; check that no downstream URL points back at the gateway", hops, maxHops),
+			})
+			return
+		}
+
+		c.Request = c.Request.WithContext(service.WithHops(c.Request.Context(), hops))
+		c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoopGuardCutsOffALoopAtTheHopLimit(t *testing.T) {
+	service.SetRetryPolicy(time.Millisecond, time.Millisecond, 0)
+	t.Cleanup(func() { service.SetRetryPolicy(100*time.Millisecond, 2*time.Second, 2) })
+
+	// A gateway whose only downstream is itself.
+	var entered, rejected atomic.Int32
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Next()
+		if c.Writer.Status() == http.StatusLoopDetected {
+			rejected.Add(1)
+		}
+	}, LoopGuard(3))
+	router.GET("/loop/:id", func(c *gin.Context) {
+		entered.Add(1)
+		if _, err := service.HTTPFetcher("loop", "/loop/")(c.Request.Context(), c.Param("id")); err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{})
+	})
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+	service.SetFetchConfig("loop", service.FetchConfig{BaseURL: srv.URL})
+	defer service.SetFetchConfig("loop", service.FetchConfig{})
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+"/loop/u1", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// Hops 0 through 3 get in; the request arriving with 4 is the one refused.
+	if got := entered.Load(); got != 4 {
+		t.Fatalf("handler entered %d times, want 4 (the limit of 3 hops plus the original)", got)
+	}
+	if got := rejected.Load(); got != 1 {
+		t.Fatalf("%d requests rejected with 508, want 1", got)
+	}
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("outermost status = %d, want %d from the failed fetch", resp.StatusCode, http.StatusBadGateway)
+	}
+}
+
+func TestLoopGuardChecksTheHopHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(LoopGuard(3))
+	router.GET("/", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for hops, want := range map[string]int{
+		"":    http.StatusOK,
+		"3":   http.StatusOK,
+		"4":   http.StatusLoopDetected,
+		"two": http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if hops != "" {
+			req.Header.Set(service.HopsHeader, hops)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%s %q: status = %d, want %d", service.HopsHeader, hops, w.Code, want)
+		}
+	}
+}
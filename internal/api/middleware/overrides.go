@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureOverrideHeader carries per-request feature overrides, e.g. "cache=off,strategy=channels".
+const FeatureOverrideHeader = "X-Feature-Override"
+
+// UpstreamOverrideHeader points every downstream call of one request at another host, e.g.
+// "http://test-host:9090".
+const UpstreamOverrideHeader = "X-Upstream-Override"
+
+// featureOverridesKey is the gin context key holding the request's overrides.
+const featureOverridesKey = "overrides.features"
+
+// featureValues are the overridable features and the values each takes. A nil list leaves the
+// value to whatever reads the override (see FeatureOverride).
+var featureValues = map[string][]string{
+	"cache":    {"on", "off"},
+	"strategy": nil,
+}
+
+// FeatureOverrides lets a caller whose token holds role switch features for just their request
+// with the X-Feature-Override header:
+//   - cache=off fetches every service live, neither reading nor filling the response cache
+//   - strategy=<name> runs the aggregate with another fan-out strategy (see
+//     handlers.WithStrategyOverride)
+//
+// and send its downstream calls to another http(s) host with the X-Upstream-Override header
+// (see service.WithUpstream), e.g. to try a test backend.
+//
+// Either header from anyone else gets a 403, and an unknown feature or value, or an upstream
+// that isn't a bare http(s) scheme and host, a 400. It must run after Authenticate.
+func FeatureOverrides(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := strings.TrimSpace(c.GetHeader(FeatureOverrideHeader))
+		upstream := strings.TrimSpace(c.GetHeader(UpstreamOverrideHeader))
+		if raw == "" && upstream == "" {
+			c.Next()
+			return
+		}
+		if claims, ok := Claims(c); !ok || !claims.HasRole(role) {
+			header := FeatureOverrideHeader
+			if raw == "" {
+				header = UpstreamOverrideHeader
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": header + " requires role " + role})
+			return
+		}
+		if upstream != "" {
+			base, err := url.Parse(upstream)
+			if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" ||
+				base.User != nil || strings.Trim(base.Path, "/") != "" || base.RawQuery != "" || base.Fragment != "" {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid " + UpstreamOverrideHeader + " " + upstream})
+				return
+			}
+			c.Request = c.Request.WithContext(service.WithUpstream(c.Request.Context(), base))
+		}
+		if raw == "" {
+			c.Next()
+			return
+		}
+
+		overrides := make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			allowed, known := featureValues[name]
+			if !ok || !known || value == "" || (allowed != nil && !slices.Contains(allowed, value)) {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid " + FeatureOverrideHeader + " entry " + pair})
+				return
+			}
+			overrides[name] = value
+		}
+		c.Set(featureOverridesKey, overrides)
+		if overrides["cache"] == "off" {
+			c.Request = c.Request.WithContext(service.WithCacheBypass(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// FeatureOverride returns the value the request's X-Feature-Override header set for feature, as
+// accepted by FeatureOverrides. ok is false if it set none.
+func FeatureOverride(c *gin.Context, feature string) (value string, ok bool) {
+	v, _ := c.Get(featureOverridesKey)
+	overrides, _ := v.(map[string]string)
+	value, ok = overrides[feature]
+	return value, ok
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeatureOverridesApplyOnlyForAdmins(t *testing.T) {
+	svc := tokens.NewService([]byte("test-secret"))
+	admin, _ := svc.CreateTokenWithClaims("alice", []string{"admin"}, time.Hour)
+	user, _ := svc.CreateTokenWithClaims("bob", nil, time.Hour)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	var bypassed bool
+	var strategy string
+	router.GET("/", Authenticate(svc), FeatureOverrides("admin"), func(c *gin.Context) {
+		bypassed = service.CacheBypassed(c.Request.Context())
+		strategy, _ = FeatureOverride(c, "strategy")
+		c.Status(http.StatusOK)
+	})
+	send := func(token, header string) int {
+		bypassed, strategy = false, ""
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if header != "" {
+			req.Header.Set(FeatureOverrideHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := send(admin, "cache=off, strategy=channels"); code != http.StatusOK || !bypassed || strategy != "channels" {
+		t.Fatalf("admin: status %d, cache bypassed %v, strategy %q; want 200, true, channels", code, bypassed, strategy)
+	}
+	if code := send(admin, "cache=on"); code != http.StatusOK || bypassed {
+		t.Fatalf("admin with cache=on: status %d, cache bypassed %v; want 200, false", code, bypassed)
+	}
+	if code := send(user, "cache=off"); code != http.StatusForbidden {
+		t.Fatalf("non-admin: status %d, want %d", code, http.StatusForbidden)
+	}
+	if code := send(user, ""); code != http.StatusOK || bypassed || strategy != "" {
+		t.Fatalf("no header: status %d, cache bypassed %v, strategy %q; want 200 with no overrides", code, bypassed, strategy)
+	}
+	for _, header := range []string{"cache=maybe", "colour=blue", "cache", "strategy="} {
+		if code := send(admin, header); code != http.StatusBadRequest {
+			t.Fatalf("admin with %q: status %d, want %d", header, code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestUpstreamOverrideRoutesAdminCallsOnly(t *testing.T) {
+	var paths []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"service": "user", "backend": "test"}`))
+	}))
+	defer backend.Close()
+
+	svc := tokens.NewService([]byte("test-secret"))
+	admin, _ := svc.CreateTokenWithClaims("alice", []string{"admin"}, time.Hour)
+	user, _ := svc.CreateTokenWithClaims("bob", nil, time.Hour)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", Authenticate(svc), FeatureOverrides("admin"), func(c *gin.Context) {
+		data, err := service.FetchUser(c.Request.Context(), "1")
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, data)
+	})
+	send := func(token, upstream string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set(UpstreamOverrideHeader, upstream)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send(admin, backend.URL); w.Code != http.StatusOK || len(paths) != 1 || paths[0] != "/mock/user/1" {
+		t.Fatalf("admin: status %d, backend saw %v; want 200 with /mock/user/1: %s", w.Code, paths, w.Body)
+	}
+	if w := send(user, backend.URL); w.Code != http.StatusForbidden || len(paths) != 1 {
+		t.Fatalf("non-admin: status %d, backend saw %v; want 403 and no call", w.Code, paths)
+	}
+	for _, upstream := range []string{"ftp://test-host", "test-host:9090", "http://test-host/api", "http://u:p@test-host"} {
+		if w := send(admin, upstream); w.Code != http.StatusBadRequest {
+			t.Fatalf("admin with %q: status %d, want %d", upstream, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter throttles each client IP with its own token bucket.
+type RateLimiter struct {
+	mu         sync.Mutex
+	clients    map[string]*client
+	limit      rate.Limit
+	burst      int
+	trustProxy bool
+	idle       time.Duration
+	now        func() time.Time
+}
+
+// client is one IP's bucket and when it last made a request.
+type client struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewRateLimiter returns a limiter allowing each IP perSecond requests a second on average,
+// in bursts of up to burst. With trustProxy set the gateway is assumed to sit behind one
+// proxy, and the client IP is the last address in X-Forwarded-For (the one that proxy saw)
+// rather than the connection's; leave it off when clients connect directly, or they could
+// pick their own IP. The buckets of IPs idle for 3 minutes are dropped every minute.
+func NewRateLimiter(perSecond float64, burst int, trustProxy bool) *RateLimiter {
+	l := &RateLimiter{
+		clients:    make(map[string]*client),
+		limit:      rate.Limit(perSecond),
+		burst:      burst,
+		trustProxy: trustProxy,
+		idle:       3 * time.Minute,
+		now:        time.Now,
+	}
+	go l.janitor(time.Minute)
+	return l
+}
+
+// Middleware answers 429, with Retry-After in whole seconds, to a client out of tokens.
+func (l *RateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reservation := l.bucket(l.clientIP(c.Request)).ReserveN(l.now(), 1)
+		if !reservation.OK() {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		if delay := reservation.DelayFrom(l.now()); delay > 0 {
+			reservation.CancelAt(l.now())
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// bucket returns ip's limiter, creating it on first use.
+func (l *RateLimiter) bucket(ip string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cl, ok := l.clients[ip]
+	if !ok {
+		cl = &client{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = cl
+	}
+	cl.lastSeen = l.now()
+	return cl.limiter
+}
+
+// clientIP returns the IP a request is counted against.
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.trustProxy {
+		if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+			hops := strings.Split(xff[len(xff)-1], ",")
+			if ip := strings.TrimSpace(hops[len(hops)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// janitor drops the buckets of idle IPs every interval.
+func (l *RateLimiter) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.sweep()
+	}
+}
+
+// sweep deletes the buckets of IPs not seen for l.idle. A bucket idle that long has normally
+// refilled, so a returning client starts where it would have anyway.
+func (l *RateLimiter) sweep() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for ip, cl := range l.clients {
+		if now.Sub(cl.lastSeen) >= l.idle {
+			delete(l.clients, ip)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestLimiter returns a limiter whose clock reads *now, and a router behind it.
+func newTestLimiter(perSecond float64, burst int, trustProxy bool, now *time.Time) (*RateLimiter, *gin.Engine) {
+	gin.SetMode(gin.TestMode)
+	l := NewRateLimiter(perSecond, burst, trustProxy)
+	l.now = func() time.Time { return *now }
+	router := gin.New()
+	router.Use(l.Middleware())
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return l, router
+}
+
+// get sends a request from remoteAddr, with an X-Forwarded-For header unless xff is empty.
+func get(router *gin.Engine, remoteAddr, xff string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if xff != "" {
+		req.Header.Set("X-Forwarded-For", xff)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiterThrottlesEachIP(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	_, router := newTestLimiter(0.5, 3, false, &now)
+
+	for i := range 3 {
+		if w := get(router, "10.0.0.1:1234", ""); w.Code != http.StatusOK {
+			t.Fatalf("request %d within the burst: status %d", i, w.Code)
+		}
+	}
+	w := get(router, "10.0.0.1:5678", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("request past the burst: status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = %q, want 2", got)
+	}
+
+	// Another IP has its own bucket.
+	if w := get(router, "10.0.0.2:1234", ""); w.Code != http.StatusOK {
+		t.Fatalf("other IP: status %d", w.Code)
+	}
+
+	// A refused request costs nothing, so a token is back after Retry-After.
+	now = now.Add(2 * time.Second)
+	if w := get(router, "10.0.0.1:1234", ""); w.Code != http.StatusOK {
+		t.Fatalf("request after Retry-After: status %d", w.Code)
+	}
+}
+
+func TestRateLimiterClientIP(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Behind a proxy every request shares its address; the last X-Forwarded-For hop tells them apart.
+	_, router := newTestLimiter(1, 1, true, &now)
+	if w := get(router, "10.0.0.9:1234", "203.0.113.7, 198.51.100.1"); w.Code != http.StatusOK {
+		t.Fatalf("first client: status %d", w.Code)
+	}
+	if w := get(router, "10.0.0.9:1234", "203.0.113.7, 198.51.100.2"); w.Code != http.StatusOK {
+		t.Fatalf("second client: status %d", w.Code)
+	}
+	if w := get(router, "10.0.0.9:1234", "192.0.2.1, 198.51.100.1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("first client with a spoofed hop: status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+
+	// Without trustProxy the header is ignored.
+	_, router = newTestLimiter(1, 1, false, &now)
+	get(router, "10.0.0.9:1234", "198.51.100.1")
+	if w := get(router, "10.0.0.9:1234", "198.51.100.2"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("untrusted X-Forwarded-For: status %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestRateLimiterSweepDropsIdleIPs(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l, router := newTestLimiter(1, 1, false, &now)
+	get(router, "10.0.0.1:1234", "")
+	now = now.Add(time.Minute)
+	get(router, "10.0.0.2:1234", "")
+
+	now = now.Add(2 * time.Minute)
+	l.sweep()
+	if _, ok := l.clients["10.0.0.1"]; ok {
+		t.Fatal("idle IP's bucket survived the sweep")
+	}
+	if _, ok := l.clients["10.0.0.2"]; !ok {
+		t.Fatal("recent IP's bucket was swept")
+	}
+}
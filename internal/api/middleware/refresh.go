@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tokens"
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshedTokenHeader carries the token RefreshNearExpiry issued in place of the request's.
+const RefreshedTokenHeader = "X-Refreshed-Token"
+
+// RefreshNearExpiry swaps a request's token that expires within window for a new one from svc
+// (see tokens.Service.RefreshToken) that expires after ttl, returning it in the X-Refreshed-Token
+// response header, so an active client never has to log in again. The old token is revoked by
+// the swap: the client must use the new one from then on, and a request still carrying the old
+// one gets a 401 like any revoked token's. A token that can't be refreshed, e.g. because a
+// concurrent request already did, is left as it is. It must run after Authenticate.
+func RefreshNearExpiry(svc *tokens.Service, window, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := Claims(c)
+		if !ok || claims.ExpiresAt == nil || time.Until(claims.ExpiresAt.Time) > window {
+			c.Next()
+			return
+		}
+		token, _ := BearerToken(c)
+		if fresh, err := svc.RefreshToken(token, ttl); err == nil {
+			c.Header(RefreshedTokenHeader, fresh)
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in both directions, and on to downstreams.
+const RequestIDHeader = "X-Request-ID"
+
+// requestStartKey is the gin context key holding when RequestLogger first saw the request.
+const requestStartKey = "request.start"
+
+// RequestLogger gives every request an ID and logs one JSON line per request to logger
+// with its method, path, status and latency, under the request's final context so a
+// tracing.LogHandler can add its trace id.
+//
+// An inbound X-Request-ID is kept so IDs can be correlated across hops; otherwise a random
+// one is generated. The ID is echoed in the response header and stored in the request
+// context (see service.WithRequestID) so fetchers forward it downstream. Register it first:
+// the time it first sees the request is what RequestStart reports.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Set(requestStartKey, start)
+
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(service.WithRequestID(c.Request.Context(), id))
+
+		c.Next()
+
+		logger.InfoContext(c.Request.Context(), "request",
+			"request_id", id,
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency_ms", time.Since(start).Milliseconds(),
+		)
+	}
+}
+
+// RequestStart returns when RequestLogger first saw the request. ok is false if it didn't.
+func RequestStart(c *gin.Context) (start time.Time, ok bool) {
+	v, ok := c.Get(requestStartKey)
+	if !ok {
+		return time.Time{}, false
+	}
+	start, ok = v.(time.Time)
+	return start, ok
+}
+
+// newRequestID returns 16 random bytes, hex-encoded.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
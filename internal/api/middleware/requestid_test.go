@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestLoggerTagsTheRequestID(t *testing.T) {
+	var downstream string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstream = r.Header.Get(RequestIDHeader)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer srv.Close()
+
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(RequestLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	router.GET("/api/aggregate", func(c *gin.Context) {
+		if _, err := service.FetchUserWithConfig(c.Request.Context(), "1", service.FetchConfig{BaseURL: srv.URL}); err != nil {
+			t.Errorf("fetch: %v", err)
+		}
+		c.Status(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		name, inbound string
+	}{
+		{"generated", ""},
+		{"inbound kept", "req-from-upstream"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			downstream = ""
+			req := httptest.NewRequest(http.MethodGet, "/api/aggregate", nil)
+			if tt.inbound != "" {
+				req.Header.Set(RequestIDHeader, tt.inbound)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			if id == "" || (tt.inbound != "" && id != tt.inbound) {
+				t.Fatalf("response %s = %q, want %q (or a generated one)", RequestIDHeader, id, tt.inbound)
+			}
+			var line map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+				t.Fatalf("log line %q: %v", buf.String(), err)
+			}
+			if line["request_id"] != id || line["method"] != "GET" || line["path"] != "/api/aggregate" || line["status"] != float64(http.StatusTeapot) {
+				t.Fatalf("log line = %v, want request_id %q, GET /api/aggregate, status 418", line, id)
+			}
+			if _, ok := line["latency_ms"]; !ok {
+				t.Fatalf("log line = %v, want latency_ms", line)
+			}
+			if downstream != id {
+				t.Fatalf("downstream got %s %q, want %q", RequestIDHeader, downstream, id)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TraceContext continues the trace named by the request's W3C traceparent header, if any: the
+// request context carries its span context, so the gateway's spans join the caller's trace and
+// the request's log lines (see tracing.LogHandler) carry its trace id, whether or not spans are
+// exported.
+func TraceContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := propagation.TraceContext{}.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestLogCarriesTheTraceID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger := slog.New(tracing.LogHandler(slog.NewJSONHandler(&buf, nil)))
+	router := gin.New()
+	router.Use(RequestLogger(logger), TraceContext())
+	router.GET("/api/aggregate", func(c *gin.Context) {
+		logger.InfoContext(c.Request.Context(), "handling")
+		c.Status(http.StatusOK)
+	})
+
+	const traceID, spanID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	tests := []struct {
+		name, traceparent, wantTrace, wantSpan string
+	}{
+		{"traced", "00-" + traceID + "-" + spanID + "-01", traceID, spanID},
+		{"untraced", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/api/aggregate", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			dec := json.NewDecoder(&buf)
+			lines := 0
+			for ; dec.More(); lines++ {
+				var line map[string]any
+				if err := dec.Decode(&line); err != nil {
+					t.Fatal(err)
+				}
+				got, _ := line["trace_id"].(string)
+				span, _ := line["span_id"].(string)
+				if got != tt.wantTrace || span != tt.wantSpan {
+					t.Fatalf("%q line: trace_id %q, span_id %q; want %q, %q", line["msg"], got, span, tt.wantTrace, tt.wantSpan)
+				}
+			}
+			if lines != 2 {
+				t.Fatalf("logged %d lines, want 2", lines)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+// Package status decides the HTTP status code of an aggregate response.
+package status
+
+import "net/http"
+
+// FromResults returns the status for an aggregate with the given successful results and
+// failed services:
+//   - every requested service failed: 502, there is nothing to show
+//   - some failed: 200, the failures are listed in the response's errors (a 207-style partial result)
+//   - none failed: 200
+//
+// An aggregate over zero services has nothing that failed, so it is a 200.
+func FromResults(results map[string]any, errors []string) int {
+	if len(errors) > 0 && len(results) == 0 {
+		return http.StatusBadGateway
+	}
+	return http.StatusOK
+}
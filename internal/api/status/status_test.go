@@ -0,0 +1,28 @@
+package status
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFromResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		results map[string]any
+		errors  []string
+		want    int
+	}{
+		{"all ok", map[string]any{"user": 1, "orders": 2}, nil, http.StatusOK},
+		{"partial", map[string]any{"user": 1}, []string{"orders: boom"}, http.StatusOK},
+		{"all failed", nil, []string{"user: boom", "orders: boom"}, http.StatusBadGateway},
+		{"all failed, empty results", map[string]any{}, []string{"user: boom"}, http.StatusBadGateway},
+		{"no services", nil, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromResults(tt.results, tt.errors); got != tt.want {
+				t.Fatalf("FromResults(%v, %v) = %d, want %d", tt.results, tt.errors, got, tt.want)
+			}
+		})
+	}
+}
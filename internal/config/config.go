@@ -0,0 +1,620 @@
+// Package config loads the gateway's settings from the environment.
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/transform"
+)
+
+// Config is the gateway's typed configuration.
+type Config struct {
+	// FetchConfigs maps every registered service to how it is called: always its base URL, plus
+	// whichever of its timeout, payload size and deadline share are set. Fields left zero keep the
+	// service's current setting (see service.MergeFetchConfig).
+	FetchConfigs map[string]service.FetchConfig
+
+	// PinnedKeys maps a downstream host to the public-key fingerprints its TLS
+	// certificates must carry (see service.SetPinnedKeys).
+	PinnedKeys map[string][]string
+
+	// CacheWrites maps a service to what a write through the gateway does to its cached
+	// responses (see service.Cache.SetWritePolicy). Services not listed keep the default.
+	CacheWrites map[string]service.WritePolicy
+
+	// StaleIfError maps a service to how long past its TTL a cached response may stand in for a
+	// failed fetch (see service.Cache.SetStaleIfError).
+	StaleIfError map[string]time.Duration
+
+	// Correlations maps a service to the header it gets the request ID in (see service.SetCorrelation).
+	// Services not listed keep service.DefaultCorrelation.
+	Correlations map[string]service.Correlation
+
+	// TimeoutEscalations maps a service to the adaptive timeout its calls are under
+	// (see service.SetTimeoutEscalation).
+	TimeoutEscalations map[string]*service.TimeoutEscalation
+
+	// ProbeTimeouts maps a service to the timeout its circuit breaker's half-open probes run under
+	// (see service.Breaker.SetProbeTimeout).
+	ProbeTimeouts map[string]time.Duration
+
+	// FallbackServices maps a service to the one that answers for it while its circuit breaker is
+	// open (see service.SetFallbackService).
+	FallbackServices map[string]string
+
+	// PreloadHints maps a service to the URLs aggregate responses including its data hint the
+	// client to preload (see handlers.SetPreloadHints).
+	PreloadHints map[string][]string
+
+	// ResponseTemplates maps a service to the templates its responses are reshaped with, by client type.
+	ResponseTemplates map[string]transform.Variants
+
+	// HealthScore is how /admin/health-scores weighs errors against latency, and the p95 latency
+	// SLOs it scores against (see service.SetHealthScoreConfig).
+	HealthScore service.HealthScoreConfig
+
+	// JWTSecret signs and verifies the bearer tokens the aggregate routes require.
+	// Empty, with no JWTKeys either, leaves those routes open (see AuthEnabled), which needs AuthDisabled.
+	JWTSecret string
+
+	// AuthDisabled is the explicit opt-out for running without JWTSecret or JWTKeys; without it
+	// the gateway refuses to start unauthenticated.
+	AuthDisabled bool
+
+	// JWTKeys maps key ids to the secrets tokens may be signed with, for rotating keys; JWTSigningKey
+	// is the id new tokens are signed with. JWTSecret, if also set, verifies tokens without a kid.
+	JWTKeys       map[string]string
+	JWTSigningKey string
+
+	// JWTLeeway is how far past its expiry (or before its not-before time) a token is still accepted.
+	JWTLeeway time.Duration
+
+	// JWTRefreshWindow is how long before its expiry a token is swapped for a fresh one,
+	// returned in X-Refreshed-Token (see middleware.RefreshNearExpiry). Zero turns that off.
+	JWTRefreshWindow time.Duration
+
+	// RouteScopes maps an aggregate route, e.g. "/api/aggregate/async" or "POST /api/aggregate",
+	// to the scopes a token needs to call it (see middleware.RequireRouteScopes).
+	RouteScopes map[string][]string
+
+	// CriticalServices are the services /health/ready needs up to report the gateway ready.
+	CriticalServices []string
+
+	// MaxOutbound caps the downstream calls in flight at once (see service.SetMaxConcurrency).
+	// Zero leaves them uncapped.
+	MaxOutbound int
+
+	// ForwardHeadersMaxBytes caps the size of the headers forwarded on each downstream call
+	// (see middleware.ForwardHeaders).
+	ForwardHeadersMaxBytes int
+
+	// BreakerGrouping is whether each service has its own circuit breaker or the services on one
+	// host share one (see service.SetBreakerGrouping).
+	BreakerGrouping service.BreakerGrouping
+
+	// OutageRatio is the share of an aggregate's services with an open circuit breaker above which
+	// it fails fast with a 503 (see handlers.SetOutageRatio). Zero turns that off.
+	OutageRatio float64
+
+	// AsyncCallbackHosts are the only hosts async aggregations may POST their result to.
+	// Empty allows any public address (see jobs.Manager.SetCallbackHosts).
+	AsyncCallbackHosts []string
+}
+
+// Load reads the configuration from the environment. Each registered service's base URL
+// comes from <NAME>_SERVICE_URL (e.g. USER_SERVICE_URL, ORDERS_SERVICE_URL) and falls back
+// to service.DefaultBaseURL. <NAME>_TIMEOUT (a duration such as "2s", the budget for the whole
+// call, retries included), <NAME>_ATTEMPT_TIMEOUT (a duration, for each attempt), <NAME>_PAYLOAD ("small",
+// "medium" or "large") and <NAME>_SHARE (a fraction in (0, 1]) set the rest of the service's
+// service.FetchConfig. <NAME>_TIMEOUT_ESCALATION ("base,min,factor,recover_after", e.g.
+// "2s,250ms,0.5,3") cuts the service's timeout from base by factor on every timeout, down to min,
+// and raises it a step again after recover_after successes (see service.NewTimeoutEscalation).
+// <NAME>_PROBE_TIMEOUT (a duration) is the shorter timeout the service's circuit breaker gives
+// the probe it lets through once open, and <NAME>_FALLBACK_SERVICE the name of another service
+// that answers for it while its breaker is open.
+// <NAME>_SERVICE_PINS optionally pins an https service to a
+// comma-separated list of hex SHA-256 public-key fingerprints, and <NAME>_CACHE_WRITE
+// ("invalidate" or "write-through") sets the service's cache write policy, and
+// <NAME>_STALE_IF_ERROR (a duration) how long past its TTL a cached response may be served when
+// the service fails.
+// <NAME>_CORRELATION_HEADER and <NAME>_CORRELATION_FORMAT ("raw" or "traceparent") set the header
+// the service gets the request ID in; either may be given alone.
+// <NAME>_PRELOAD_HINTS is a comma-separated list of paths or absolute http(s) URLs to hint
+// with a Link preload header whenever the service's data is in an aggregate response.
+// <NAME>_RESPONSE_TEMPLATE is a text/template reshaping the service's responses (see
+// transform.Template); it is parsed here, so a broken template fails at startup.
+// <NAME>_RESPONSE_TEMPLATE_<CLIENT> (e.g. USER_RESPONSE_TEMPLATE_MOBILE) is the template used
+// instead for requests from that client type (see transform.Variants).
+// HEALTH_ERROR_WEIGHT and HEALTH_LATENCY_WEIGHT (non-negative numbers, not both zero) weigh a
+// service's error rate against its latency in its health score, HEALTH_SLO (a duration) is the
+// p95 latency services are scored against, and <NAME>_SLO overrides it for one service; unset
+// ones keep service.DefaultHealthScoreConfig.
+// JWT_SECRET is the bearer-token secret, and JWT_LEEWAY (a duration such as "30s") the clock
+// skew tolerated when verifying tokens. JWT_REFRESH_WINDOW (a duration, default "5m"; "0" turns it
+// off) is how close to expiry a request's token is transparently refreshed. JWT_KEYS ("kid:secret,kid:secret") and JWT_SIGNING_KEY
+// (one of its kids, optional when there is just one) rotate signing keys (see tokens.Service.SetKeys).
+// One of JWT_SECRET and JWT_KEYS is required unless AUTH_DISABLED is "true".
+// ROUTE_SCOPES lists the scopes aggregate routes need as semicolon-separated route=scopes
+// entries, the scopes space-separated, e.g.
+// "POST /api/aggregate=aggregate:write;/api/aggregate/async=aggregate:write jobs:read".
+// CRITICAL_SERVICES is a comma-separated list of the services readiness depends on; it defaults
+// to every service.
+// MAX_OUTBOUND_CONCURRENCY (a positive integer) caps the downstream calls in flight at once.
+// FORWARD_HEADERS_MAX_BYTES (a positive integer, default 8192) caps the forwarded header bytes.
+// OUTAGE_BREAKER_RATIO (a fraction in (0, 1]) is the share of open circuit breakers above which
+// aggregates fail fast. BREAKER_GROUPING is "service" (the default) or "host".
+// ASYNC_CALLBACK_HOSTS is a comma-separated list of the hosts async callbacks may go to.
+// It fails if a URL isn't an absolute http(s) URL, a timeout, attempt timeout, payload size, share, timeout
+// escalation, probe timeout, stale-if-error window, SLO, health score weight, pin or preload hint is malformed, a write policy or correlation format is unknown,
+// a template doesn't parse, BREAKER_GROUPING is unknown, JWT_KEYS, JWT_SIGNING_KEY, JWT_LEEWAY, JWT_REFRESH_WINDOW, AUTH_DISABLED or ROUTE_SCOPES is malformed,
+// neither JWT_SECRET nor JWT_KEYS is set without AUTH_DISABLED (or either is set with it), a critical
+// or fallback service isn't registered, or MAX_OUTBOUND_CONCURRENCY, FORWARD_HEADERS_MAX_BYTES or
+// OUTAGE_BREAKER_RATIO is out of range.
+func Load() (Config, error) {
+	cfg := Config{
+		FetchConfigs:           make(map[string]service.FetchConfig),
+		PinnedKeys:             make(map[string][]string),
+		CacheWrites:            make(map[string]service.WritePolicy),
+		StaleIfError:           make(map[string]time.Duration),
+		Correlations:           make(map[string]service.Correlation),
+		TimeoutEscalations:     make(map[string]*service.TimeoutEscalation),
+		ProbeTimeouts:          make(map[string]time.Duration),
+		FallbackServices:       make(map[string]string),
+		PreloadHints:           make(map[string][]string),
+		ResponseTemplates:      make(map[string]transform.Variants),
+		HealthScore:            service.DefaultHealthScoreConfig(),
+		JWTRefreshWindow:       5 * time.Minute,
+		ForwardHeadersMaxBytes: 8192,
+		BreakerGrouping:        service.BreakerPerService,
+	}
+	cfg.HealthScore.SLOs = make(map[string]time.Duration)
+	for _, name := range service.Default.Names() {
+		key := strings.ToUpper(name) + "_SERVICE_URL"
+		raw := os.Getenv(key)
+		if raw == "" {
+			raw = service.DefaultBaseURL
+		}
+		u, err := parseURL(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: %s=%q: %w", key, raw, err)
+		}
+		fetch, err := loadFetchConfig(strings.ToUpper(name))
+		if err != nil {
+			return Config{}, err
+		}
+		fetch.BaseURL = raw
+		cfg.FetchConfigs[name] = fetch
+
+		escalationKey := strings.ToUpper(name) + "_TIMEOUT_ESCALATION"
+		if rawEscalation := os.Getenv(escalationKey); rawEscalation != "" {
+			escalation, err := parseEscalation(rawEscalation)
+			if err != nil {
+				return Config{}, fmt.Errorf("config: %s=%q: %w", escalationKey, rawEscalation, err)
+			}
+			cfg.TimeoutEscalations[name] = escalation
+		}
+
+		sloKey := strings.ToUpper(name) + "_SLO"
+		if rawSLO := os.Getenv(sloKey); rawSLO != "" {
+			slo, err := parseSLO(rawSLO)
+			if err != nil {
+				return Config{}, fmt.Errorf("config: %s=%q: %w", sloKey, rawSLO, err)
+			}
+			cfg.HealthScore.SLOs[name] = slo
+		}
+
+		probeKey := strings.ToUpper(name) + "_PROBE_TIMEOUT"
+		if rawProbe := os.Getenv(probeKey); rawProbe != "" {
+			timeout, err := time.ParseDuration(rawProbe)
+			if err == nil && timeout <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+			if err != nil {
+				return Config{}, fmt.Errorf("config: %s=%q: %w", probeKey, rawProbe, err)
+			}
+			cfg.ProbeTimeouts[name] = timeout
+		}
+
+		fallbackKey := strings.ToUpper(name) + "_FALLBACK_SERVICE"
+		if other := os.Getenv(fallbackKey); other != "" {
+			var err error
+			if _, ok := service.Default.Get(other); !ok {
+				err = fmt.Errorf("unknown service")
+			} else if other == name {
+				err = fmt.Errorf("a service can't fall back to itself")
+			}
+			if err != nil {
+				return Config{}, fmt.Errorf("config: %s=%q: %w", fallbackKey, other, err)
+			}
+			cfg.FallbackServices[name] = other
+		}
+
+		pinKey := strings.ToUpper(name) + "_SERVICE_PINS"
+		if rawPins := os.Getenv(pinKey); rawPins != "" {
+			pins, err := parsePins(rawPins)
+			if err == nil && u.Scheme != "https" {
+				err = fmt.Errorf("pins need an https %s", key)
+			}
+			if err != nil {
+				return Config{}, fmt.Errorf("config: %s: %w", pinKey, err)
+			}
+			cfg.PinnedKeys[u.Hostname()] = append(cfg.PinnedKeys[u.Hostname()], pins...)
+		}
+
+		writeKey := strings.ToUpper(name) + "_CACHE_WRITE"
+		if rawPolicy := os.Getenv(writeKey); rawPolicy != "" {
+			switch policy := service.WritePolicy(rawPolicy); policy {
+			case service.WriteInvalidate, service.WriteThrough:
+				cfg.CacheWrites[name] = policy
+			default:
+				return Config{}, fmt.Errorf("config: %s=%q: must be %q or %q", writeKey, rawPolicy, service.WriteInvalidate, service.WriteThrough)
+			}
+		}
+
+		staleKey := strings.ToUpper(name) + "_STALE_IF_ERROR"
+		if rawStale := os.Getenv(staleKey); rawStale != "" {
+			window, err := time.ParseDuration(rawStale)
+			if err == nil && window <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+			if err != nil {
+				return Config{}, fmt.Errorf("config: %s=%q: %w", staleKey, rawStale, err)
+			}
+			cfg.StaleIfError[name] = window
+		}
+
+		headerKey := strings.ToUpper(name) + "_CORRELATION_HEADER"
+		formatKey := strings.ToUpper(name) + "_CORRELATION_FORMAT"
+		rawHeader, rawFormat := os.Getenv(headerKey), os.Getenv(formatKey)
+		if rawHeader != "" || rawFormat != "" {
+			correlation := service.DefaultCorrelation
+			if rawHeader != "" {
+				correlation.Header = rawHeader
+			}
+			switch format := service.CorrelationFormat(rawFormat); format {
+			case service.CorrelationRaw, service.CorrelationTraceparent:
+				correlation.Format = format
+			case "":
+				// keep the default format
+			default:
+				return Config{}, fmt.Errorf("config: %s=%q: must be %q or %q", formatKey, rawFormat, service.CorrelationRaw, service.CorrelationTraceparent)
+			}
+			cfg.Correlations[name] = correlation
+		}
+
+		hintsKey := strings.ToUpper(name) + "_PRELOAD_HINTS"
+		if rawHints := os.Getenv(hintsKey); rawHints != "" {
+			for _, hint := range strings.Split(rawHints, ",") {
+				hint = strings.TrimSpace(hint)
+				if err := checkPreloadHint(hint); err != nil {
+					return Config{}, fmt.Errorf("config: %s: %q: %w", hintsKey, hint, err)
+				}
+				cfg.PreloadHints[name] = append(cfg.PreloadHints[name], hint)
+			}
+		}
+
+		templateKey := strings.ToUpper(name) + "_RESPONSE_TEMPLATE"
+		variants := transform.Variants{ByClient: make(map[string]*transform.Template)}
+		for _, kv := range os.Environ() {
+			key, rawTemplate, _ := strings.Cut(kv, "=")
+			client, isVariant := strings.CutPrefix(key, templateKey+"_")
+			if rawTemplate == "" || (key != templateKey && (!isVariant || client == "")) {
+				continue
+			}
+			tmpl, err := transform.ParseTemplate(name, rawTemplate)
+			if err != nil {
+				return Config{}, fmt.Errorf("config: %s: %w", key, err)
+			}
+			if isVariant {
+				variants.ByClient[strings.ToLower(client)] = tmpl
+			} else {
+				variants.Default = tmpl
+			}
+		}
+		if variants.Default != nil || len(variants.ByClient) > 0 {
+			cfg.ResponseTemplates[name] = variants
+		}
+	}
+
+	for key, weight := range map[string]*float64{
+		"HEALTH_ERROR_WEIGHT":   &cfg.HealthScore.ErrorWeight,
+		"HEALTH_LATENCY_WEIGHT": &cfg.HealthScore.LatencyWeight,
+	} {
+		raw := os.Getenv(key)
+		if raw == "" {
+			continue
+		}
+		w, err := strconv.ParseFloat(raw, 64)
+		if err == nil && (w < 0 || math.IsInf(w, 0) || math.IsNaN(w)) {
+			err = fmt.Errorf("must be a non-negative number")
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("config: %s=%q: %w", key, raw, err)
+		}
+		*weight = w
+	}
+	if cfg.HealthScore.ErrorWeight+cfg.HealthScore.LatencyWeight <= 0 {
+		return Config{}, fmt.Errorf("config: HEALTH_ERROR_WEIGHT and HEALTH_LATENCY_WEIGHT must not both be zero")
+	}
+	if raw := os.Getenv("HEALTH_SLO"); raw != "" {
+		slo, err := parseSLO(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: HEALTH_SLO=%q: %w", raw, err)
+		}
+		cfg.HealthScore.DefaultSLO = slo
+	}
+
+	cfg.JWTSecret = os.Getenv("JWT_SECRET")
+	if raw := os.Getenv("JWT_KEYS"); raw != "" {
+		cfg.JWTKeys = make(map[string]string)
+		for _, pair := range strings.Split(raw, ",") {
+			kid, secret, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || kid == "" || secret == "" {
+				return Config{}, fmt.Errorf("config: JWT_KEYS: %q is not kid:secret", pair)
+			}
+			cfg.JWTKeys[kid] = secret
+		}
+		cfg.JWTSigningKey = os.Getenv("JWT_SIGNING_KEY")
+		if cfg.JWTSigningKey == "" && len(cfg.JWTKeys) == 1 {
+			for kid := range cfg.JWTKeys {
+				cfg.JWTSigningKey = kid
+			}
+		}
+		if _, ok := cfg.JWTKeys[cfg.JWTSigningKey]; !ok {
+			return Config{}, fmt.Errorf("config: JWT_SIGNING_KEY=%q: must be one of the JWT_KEYS ids", cfg.JWTSigningKey)
+		}
+	}
+	if raw := os.Getenv("AUTH_DISABLED"); raw != "" {
+		disabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return Config{}, fmt.Errorf("config: AUTH_DISABLED=%q: %w", raw, err)
+		}
+		cfg.AuthDisabled = disabled
+	}
+	switch {
+	case !cfg.AuthEnabled() && !cfg.AuthDisabled:
+		return Config{}, fmt.Errorf("config: JWT_SECRET or JWT_KEYS is required; set AUTH_DISABLED=true to run without authentication")
+	case cfg.AuthEnabled() && cfg.AuthDisabled:
+		return Config{}, fmt.Errorf("config: AUTH_DISABLED=true: conflicts with JWT_SECRET or JWT_KEYS being set")
+	}
+	if raw := os.Getenv("JWT_LEEWAY"); raw != "" {
+		leeway, err := time.ParseDuration(raw)
+		if err == nil && leeway < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("config: JWT_LEEWAY=%q: %w", raw, err)
+		}
+		cfg.JWTLeeway = leeway
+	}
+	if raw := os.Getenv("JWT_REFRESH_WINDOW"); raw != "" {
+		window, err := time.ParseDuration(raw)
+		if err == nil && window < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("config: JWT_REFRESH_WINDOW=%q: %w", raw, err)
+		}
+		cfg.JWTRefreshWindow = window
+	}
+
+	if raw := os.Getenv("ROUTE_SCOPES"); raw != "" {
+		cfg.RouteScopes = make(map[string][]string)
+		for _, entry := range strings.Split(raw, ";") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			route, scopes, ok := strings.Cut(entry, "=")
+			route = strings.TrimSpace(route)
+			pattern := route
+			if method, path, ok := strings.Cut(route, " "); ok && method == strings.ToUpper(method) {
+				pattern = strings.TrimSpace(path)
+			}
+			fields := strings.Fields(scopes)
+			if !ok || !strings.HasPrefix(pattern, "/") || len(fields) == 0 {
+				return Config{}, fmt.Errorf("config: ROUTE_SCOPES: %q is not route=scope [scope...]", entry)
+			}
+			cfg.RouteScopes[route] = fields
+		}
+	}
+
+	cfg.CriticalServices = service.Default.Names()
+	if raw := os.Getenv("CRITICAL_SERVICES"); raw != "" {
+		cfg.CriticalServices = nil
+		for _, name := range strings.Split(raw, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, ok := service.Default.Get(name); !ok {
+				return Config{}, fmt.Errorf("config: CRITICAL_SERVICES=%q: unknown service %q", raw, name)
+			}
+			cfg.CriticalServices = append(cfg.CriticalServices, name)
+		}
+	}
+
+	if raw := os.Getenv("MAX_OUTBOUND_CONCURRENCY"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err == nil && n < 1 {
+			err = fmt.Errorf("must be positive")
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("config: MAX_OUTBOUND_CONCURRENCY=%q: %w", raw, err)
+		}
+		cfg.MaxOutbound = n
+	}
+	if raw := os.Getenv("BREAKER_GROUPING"); raw != "" {
+		switch g := service.BreakerGrouping(raw); g {
+		case service.BreakerPerService, service.BreakerPerHost:
+			cfg.BreakerGrouping = g
+		default:
+			return Config{}, fmt.Errorf("config: BREAKER_GROUPING=%q: must be %q or %q", raw, service.BreakerPerService, service.BreakerPerHost)
+		}
+	}
+	if raw := os.Getenv("FORWARD_HEADERS_MAX_BYTES"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err == nil && n < 1 {
+			err = fmt.Errorf("must be positive")
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("config: FORWARD_HEADERS_MAX_BYTES=%q: %w", raw, err)
+		}
+		cfg.ForwardHeadersMaxBytes = n
+	}
+	if raw := os.Getenv("OUTAGE_BREAKER_RATIO"); raw != "" {
+		ratio, err := strconv.ParseFloat(raw, 64)
+		if err == nil && (ratio <= 0 || ratio > 1) {
+			err = fmt.Errorf("must be in (0, 1]")
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("config: OUTAGE_BREAKER_RATIO=%q: %w", raw, err)
+		}
+		cfg.OutageRatio = ratio
+	}
+
+	for _, host := range strings.Split(os.Getenv("ASYNC_CALLBACK_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			cfg.AsyncCallbackHosts = append(cfg.AsyncCallbackHosts, host)
+		}
+	}
+	return cfg, nil
+}
+
+// loadFetchConfig reads <prefix>_TIMEOUT, <prefix>_ATTEMPT_TIMEOUT, <prefix>_PAYLOAD and <prefix>_SHARE,
+// leaving unset ones zero.
+func loadFetchConfig(prefix string) (service.FetchConfig, error) {
+	var fetch service.FetchConfig
+	if raw := os.Getenv(prefix + "_TIMEOUT"); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err == nil && timeout <= 0 {
+			err = fmt.Errorf("must be positive")
+		}
+		if err != nil {
+			return fetch, fmt.Errorf("config: %s_TIMEOUT=%q: %w", prefix, raw, err)
+		}
+		fetch.Timeout = timeout
+	}
+	if raw := os.Getenv(prefix + "_ATTEMPT_TIMEOUT"); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err == nil && timeout <= 0 {
+			err = fmt.Errorf("must be positive")
+		}
+		if err != nil {
+			return fetch, fmt.Errorf("config: %s_ATTEMPT_TIMEOUT=%q: %w", prefix, raw, err)
+		}
+		fetch.AttemptTimeout = timeout
+	}
+	if raw := os.Getenv(prefix + "_PAYLOAD"); raw != "" {
+		switch size := service.PayloadSize(raw); size {
+		case service.PayloadSmall, service.PayloadMedium, service.PayloadLarge:
+			fetch.Payload = size
+		default:
+			return fetch, fmt.Errorf("config: %s_PAYLOAD=%q: must be %q, %q or %q", prefix, raw, service.PayloadSmall, service.PayloadMedium, service.PayloadLarge)
+		}
+	}
+	if raw := os.Getenv(prefix + "_SHARE"); raw != "" {
+		share, err := strconv.ParseFloat(raw, 64)
+		if err == nil && (share <= 0 || share > 1) {
+			err = fmt.Errorf("must be in (0, 1]")
+		}
+		if err != nil {
+			return fetch, fmt.Errorf("config: %s_SHARE=%q: %w", prefix, raw, err)
+		}
+		fetch.Share = share
+	}
+	return fetch, nil
+}
+
+// parseSLO parses a p95 latency target, which must be a positive duration.
+func parseSLO(raw string) (time.Duration, error) {
+	slo, err := time.ParseDuration(raw)
+	if err == nil && slo <= 0 {
+		err = fmt.Errorf("must be positive")
+	}
+	return slo, err
+}
+
+// parseEscalation parses "base,min,factor,recover_after" into a service.TimeoutEscalation.
+func parseEscalation(raw string) (*service.TimeoutEscalation, error) {
+	fields := strings.Split(raw, ",")
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("want base,min,factor,recover_after")
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	base, err := time.ParseDuration(fields[0])
+	if err != nil || base <= 0 {
+		return nil, fmt.Errorf("base %q must be a positive duration", fields[0])
+	}
+	floor, err := time.ParseDuration(fields[1])
+	if err != nil || floor <= 0 || floor > base {
+		return nil, fmt.Errorf("min %q must be a positive duration no larger than base", fields[1])
+	}
+	factor, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil || factor <= 0 || factor >= 1 {
+		return nil, fmt.Errorf("factor %q must be between 0 and 1", fields[2])
+	}
+	recoverAfter, err := strconv.Atoi(fields[3])
+	if err != nil || recoverAfter < 1 {
+		return nil, fmt.Errorf("recover_after %q must be a positive integer", fields[3])
+	}
+	return service.NewTimeoutEscalation(base, floor, factor, recoverAfter), nil
+}
+
+// checkPreloadHint checks that hint is a path or an absolute http(s) URL that can go between
+// the angle brackets of a Link header.
+func checkPreloadHint(hint string) error {
+	if strings.ContainsAny(hint, "<> \t\r\n") {
+		return fmt.Errorf("must not contain spaces or angle brackets")
+	}
+	if strings.HasPrefix(hint, "/") && !strings.HasPrefix(hint, "//") {
+		return nil
+	}
+	_, err := parseURL(hint)
+	return err
+}
+
+// AuthEnabled reports whether a JWT secret or key set is configured, i.e. whether the gateway
+// requires bearer tokens.
+func (c Config) AuthEnabled() bool {
+	return c.JWTSecret != "" || len(c.JWTKeys) > 0
+}
+
+// parsePins splits a comma-separated fingerprint list, checking each is a hex SHA-256 (colons allowed).
+func parsePins(raw string) ([]string, error) {
+	var pins []string
+	for _, pin := range strings.Split(raw, ",") {
+		pin = strings.TrimSpace(pin)
+		b, err := hex.DecodeString(strings.ReplaceAll(pin, ":", ""))
+		if err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("%q is not a hex SHA-256 fingerprint", pin)
+		}
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// parseURL parses raw, checking that it is an absolute http or https URL.
+func parseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("scheme must be http or https")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing host")
+	}
+	return u, nil
+}
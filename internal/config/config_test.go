@@ -0,0 +1,264 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/concurrent-api-gateway/pkg/service"
+)
+
+// TestMain gives every test a JWT secret, which Load requires unless AUTH_DISABLED is set.
+func TestMain(m *testing.M) {
+	os.Setenv("JWT_SECRET", "test-secret")
+	os.Exit(m.Run())
+}
+
+func TestLoadHealthScoreConfig(t *testing.T) {
+	t.Setenv("HEALTH_ERROR_WEIGHT", "1")
+	t.Setenv("HEALTH_LATENCY_WEIGHT", "3")
+	t.Setenv("HEALTH_SLO", "250ms")
+	t.Setenv("ORDERS_SLO", "2s")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cfg.HealthScore
+	if got.ErrorWeight != 1 || got.LatencyWeight != 3 || got.DefaultSLO != 250*time.Millisecond {
+		t.Fatalf("HealthScore = %+v, want weights 1 and 3 against 250ms", got)
+	}
+	if got.SLOs["orders"] != 2*time.Second || len(got.SLOs) != 1 {
+		t.Fatalf("SLOs = %v, want orders at 2s", got.SLOs)
+	}
+}
+
+func TestLoadHealthScoreConfigDefaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := service.DefaultHealthScoreConfig()
+	if got := cfg.HealthScore; got.ErrorWeight != want.ErrorWeight || got.LatencyWeight != want.LatencyWeight || got.DefaultSLO != want.DefaultSLO {
+		t.Fatalf("HealthScore = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoadRejectsBadHealthScoreConfig(t *testing.T) {
+	for key, raw := range map[string]string{
+		"HEALTH_ERROR_WEIGHT":   "-1",
+		"HEALTH_LATENCY_WEIGHT": "heavy",
+		"HEALTH_SLO":            "0s",
+		"USER_SLO":              "soon",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, raw)
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), key) {
+				t.Fatalf("Load() with %s=%q = %v, want an error naming it", key, raw, err)
+			}
+		})
+	}
+
+	t.Run("both weights zero", func(t *testing.T) {
+		t.Setenv("HEALTH_ERROR_WEIGHT", "0")
+		t.Setenv("HEALTH_LATENCY_WEIGHT", "0")
+		if _, err := Load(); err == nil {
+			t.Fatal("Load() with both weights zero succeeded")
+		}
+	})
+}
+
+func TestLoadRouteScopes(t *testing.T) {
+	t.Setenv("ROUTE_SCOPES", "POST /api/aggregate=aggregate:write; /api/aggregate/async/:id=aggregate:read jobs:read")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.RouteScopes["POST /api/aggregate"]; len(got) != 1 || got[0] != "aggregate:write" {
+		t.Fatalf("POST /api/aggregate scopes = %v, want [aggregate:write]", got)
+	}
+	if got := cfg.RouteScopes["/api/aggregate/async/:id"]; len(got) != 2 || got[1] != "jobs:read" {
+		t.Fatalf("/api/aggregate/async/:id scopes = %v, want [aggregate:read jobs:read]", got)
+	}
+
+	for _, raw := range []string{"/api/aggregate", "/api/aggregate=", "api/aggregate=read", "post /api/aggregate=read"} {
+		t.Setenv("ROUTE_SCOPES", raw)
+		if _, err := Load(); err == nil {
+			t.Fatalf("Load() with ROUTE_SCOPES=%q succeeded", raw)
+		}
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range service.Default.Names() {
+		if got := cfg.FetchConfigs[name]; got != (service.FetchConfig{BaseURL: service.DefaultBaseURL}) {
+			t.Fatalf("FetchConfigs[%s] = %+v, want only the default base URL", name, got)
+		}
+	}
+	if cfg.JWTRefreshWindow != 5*time.Minute || cfg.ForwardHeadersMaxBytes != 8192 || cfg.BreakerGrouping != service.BreakerPerService {
+		t.Fatalf("JWTRefreshWindow %v, ForwardHeadersMaxBytes %d, BreakerGrouping %q; want 5m, 8192, service",
+			cfg.JWTRefreshWindow, cfg.ForwardHeadersMaxBytes, cfg.BreakerGrouping)
+	}
+	if cfg.MaxOutbound != 0 || cfg.OutageRatio != 0 || cfg.JWTLeeway != 0 || cfg.AsyncCallbackHosts != nil {
+		t.Fatalf("MaxOutbound %d, OutageRatio %v, JWTLeeway %v, AsyncCallbackHosts %v; want all unset",
+			cfg.MaxOutbound, cfg.OutageRatio, cfg.JWTLeeway, cfg.AsyncCallbackHosts)
+	}
+	if got, want := len(cfg.CriticalServices), len(service.Default.Names()); got != want {
+		t.Fatalf("CriticalServices = %v, want every service", cfg.CriticalServices)
+	}
+	if len(cfg.TimeoutEscalations)+len(cfg.ProbeTimeouts)+len(cfg.FallbackServices)+len(cfg.CacheWrites)+
+		len(cfg.StaleIfError)+len(cfg.Correlations)+len(cfg.PinnedKeys)+len(cfg.PreloadHints)+len(cfg.ResponseTemplates) != 0 {
+		t.Fatalf("per-service overrides set with none in the environment: %+v", cfg)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	const pin = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(cfg Config) bool
+	}{
+		{"base URL", map[string]string{"ORDERS_SERVICE_URL": "http://orders.internal:8080"},
+			func(cfg Config) bool { return cfg.FetchConfigs["orders"].BaseURL == "http://orders.internal:8080" }},
+		{"timeouts, payload and share", map[string]string{"USER_TIMEOUT": "2s", "USER_ATTEMPT_TIMEOUT": "500ms", "USER_PAYLOAD": "large", "USER_SHARE": "0.5"},
+			func(cfg Config) bool {
+				f := cfg.FetchConfigs["user"]
+				return f.Timeout == 2*time.Second && f.AttemptTimeout == 500*time.Millisecond && f.Payload == service.PayloadLarge && f.Share == 0.5
+			}},
+		{"timeout escalation", map[string]string{"ORDERS_TIMEOUT_ESCALATION": "2s,250ms,0.5,3"},
+			func(cfg Config) bool { return cfg.TimeoutEscalations["orders"] != nil }},
+		{"probe timeout", map[string]string{"ORDERS_PROBE_TIMEOUT": "200ms"},
+			func(cfg Config) bool { return cfg.ProbeTimeouts["orders"] == 200*time.Millisecond }},
+		{"fallback service", map[string]string{"ORDERS_FALLBACK_SERVICE": "inventory"},
+			func(cfg Config) bool { return cfg.FallbackServices["orders"] == "inventory" }},
+		{"pins", map[string]string{"USER_SERVICE_URL": "https://users.internal", "USER_SERVICE_PINS": pin},
+			func(cfg Config) bool { return len(cfg.PinnedKeys["users.internal"]) == 1 }},
+		{"cache write", map[string]string{"USER_CACHE_WRITE": "write-through"},
+			func(cfg Config) bool { return cfg.CacheWrites["user"] == service.WriteThrough }},
+		{"stale if error", map[string]string{"USER_STALE_IF_ERROR": "1m"},
+			func(cfg Config) bool { return cfg.StaleIfError["user"] == time.Minute }},
+		{"correlation", map[string]string{"ORDERS_CORRELATION_HEADER": "X-Correlation-Id"},
+			func(cfg Config) bool {
+				c := cfg.Correlations["orders"]
+				return c.Header == "X-Correlation-Id" && c.Format == service.DefaultCorrelation.Format
+			}},
+		{"preload hints", map[string]string{"USER_PRELOAD_HINTS": "/avatars/1.png, https://cdn.example.com/app.js"},
+			func(cfg Config) bool { return len(cfg.PreloadHints["user"]) == 2 }},
+		{"response templates", map[string]string{"USER_RESPONSE_TEMPLATE": `{"name": {{json .name}}}`, "USER_RESPONSE_TEMPLATE_MOBILE": `{}`},
+			func(cfg Config) bool {
+				v := cfg.ResponseTemplates["user"]
+				return v.Default != nil && v.ByClient["mobile"] != nil
+			}},
+		{"jwt", map[string]string{"JWT_SECRET": "s", "JWT_LEEWAY": "30s", "JWT_REFRESH_WINDOW": "0"},
+			func(cfg Config) bool {
+				return cfg.JWTSecret == "s" && cfg.JWTLeeway == 30*time.Second && cfg.JWTRefreshWindow == 0
+			}},
+		{"jwt keys", map[string]string{"JWT_KEYS": "old:a, new:b", "JWT_SIGNING_KEY": "new"},
+			func(cfg Config) bool { return len(cfg.JWTKeys) == 2 && cfg.JWTSigningKey == "new" }},
+		{"single jwt key signs", map[string]string{"JWT_KEYS": "only:a"},
+			func(cfg Config) bool { return cfg.JWTSigningKey == "only" }},
+		{"critical services", map[string]string{"CRITICAL_SERVICES": "user, orders"},
+			func(cfg Config) bool { return strings.Join(cfg.CriticalServices, ",") == "user,orders" }},
+		{"limits", map[string]string{"MAX_OUTBOUND_CONCURRENCY": "32", "FORWARD_HEADERS_MAX_BYTES": "1024", "OUTAGE_BREAKER_RATIO": "0.5"},
+			func(cfg Config) bool {
+				return cfg.MaxOutbound == 32 && cfg.ForwardHeadersMaxBytes == 1024 && cfg.OutageRatio == 0.5
+			}},
+		{"breaker grouping", map[string]string{"BREAKER_GROUPING": "host"},
+			func(cfg Config) bool { return cfg.BreakerGrouping == service.BreakerPerHost }},
+		{"callback hosts", map[string]string{"ASYNC_CALLBACK_HOSTS": "hooks.example.com, ci.example.com"},
+			func(cfg Config) bool {
+				return strings.Join(cfg.AsyncCallbackHosts, ",") == "hooks.example.com,ci.example.com"
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, err := Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(cfg) {
+				t.Fatalf("Load() with %v = %+v", tt.env, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		key string // the error must name it
+		env map[string]string
+	}{
+		{key: "USER_SERVICE_URL", env: map[string]string{"USER_SERVICE_URL": "localhost:9090"}},
+		{key: "USER_SERVICE_URL", env: map[string]string{"USER_SERVICE_URL": "ftp://users.internal"}},
+		{key: "USER_TIMEOUT", env: map[string]string{"USER_TIMEOUT": "-1s"}},
+		{key: "USER_ATTEMPT_TIMEOUT", env: map[string]string{"USER_ATTEMPT_TIMEOUT": "0s"}},
+		{key: "USER_PAYLOAD", env: map[string]string{"USER_PAYLOAD": "huge"}},
+		{key: "USER_SHARE", env: map[string]string{"USER_SHARE": "1.5"}},
+		{key: "ORDERS_TIMEOUT_ESCALATION", env: map[string]string{"ORDERS_TIMEOUT_ESCALATION": "2s,250ms"}},
+		{key: "ORDERS_PROBE_TIMEOUT", env: map[string]string{"ORDERS_PROBE_TIMEOUT": "soon"}},
+		{key: "ORDERS_FALLBACK_SERVICE", env: map[string]string{"ORDERS_FALLBACK_SERVICE": "nope"}},
+		{key: "ORDERS_FALLBACK_SERVICE", env: map[string]string{"ORDERS_FALLBACK_SERVICE": "orders"}},
+		{key: "USER_SERVICE_PINS", env: map[string]string{"USER_SERVICE_URL": "https://users.internal", "USER_SERVICE_PINS": "abc"}},
+		{key: "USER_SERVICE_PINS", env: map[string]string{"USER_SERVICE_PINS": strings.Repeat("ab", 32)}}, // http service
+		{key: "USER_CACHE_WRITE", env: map[string]string{"USER_CACHE_WRITE": "write-back"}},
+		{key: "USER_STALE_IF_ERROR", env: map[string]string{"USER_STALE_IF_ERROR": "0s"}},
+		{key: "ORDERS_CORRELATION_FORMAT", env: map[string]string{"ORDERS_CORRELATION_FORMAT": "b3"}},
+		{key: "USER_PRELOAD_HINTS", env: map[string]string{"USER_PRELOAD_HINTS": "/a b.png"}},
+		{key: "USER_RESPONSE_TEMPLATE", env: map[string]string{"USER_RESPONSE_TEMPLATE": "{{.name"}},
+		{key: "JWT_KEYS", env: map[string]string{"JWT_KEYS": "nosecret"}},
+		{key: "JWT_SIGNING_KEY", env: map[string]string{"JWT_KEYS": "a:x,b:y"}},
+		{key: "JWT_LEEWAY", env: map[string]string{"JWT_LEEWAY": "-1s"}},
+		{key: "JWT_REFRESH_WINDOW", env: map[string]string{"JWT_REFRESH_WINDOW": "later"}},
+		{key: "CRITICAL_SERVICES", env: map[string]string{"CRITICAL_SERVICES": "user,nope"}},
+		{key: "MAX_OUTBOUND_CONCURRENCY", env: map[string]string{"MAX_OUTBOUND_CONCURRENCY": "0"}},
+		{key: "FORWARD_HEADERS_MAX_BYTES", env: map[string]string{"FORWARD_HEADERS_MAX_BYTES": "lots"}},
+		{key: "OUTAGE_BREAKER_RATIO", env: map[string]string{"OUTAGE_BREAKER_RATIO": "0"}},
+		{key: "BREAKER_GROUPING", env: map[string]string{"BREAKER_GROUPING": "region"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Fatalf("Load() with %v = %v, want an error naming %s", tt.env, err, tt.key)
+			}
+		})
+	}
+}
+
+func TestLoadRequiresAuthUnlessDisabled(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "AUTH_DISABLED") {
+		t.Fatalf("Load() without a secret = %v, want an error pointing at AUTH_DISABLED", err)
+	}
+
+	t.Setenv("AUTH_DISABLED", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() with AUTH_DISABLED=true: %v", err)
+	}
+	if !cfg.AuthDisabled || cfg.AuthEnabled() {
+		t.Fatalf("AuthDisabled %v, AuthEnabled %v; want auth off", cfg.AuthDisabled, cfg.AuthEnabled())
+	}
+
+	t.Setenv("JWT_SECRET", "s")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "AUTH_DISABLED") {
+		t.Fatalf("Load() with both a secret and AUTH_DISABLED=true = %v, want an error", err)
+	}
+
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("AUTH_DISABLED", "sometimes")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "AUTH_DISABLED") {
+		t.Fatalf("Load() with AUTH_DISABLED=sometimes = %v, want an error naming it", err)
+	}
+}
@@ -0,0 +1,307 @@
+// Package jobs runs work in the background for async requests and POSTs each result to the
+// callback URL the client gave when submitting it.
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrQueueFull is returned by Submit when the manager already has as many jobs waiting to run as it allows.
+var ErrQueueFull = errors.New("job queue full")
+
+// ErrCallbackNotAllowed is returned by Submit and CheckCallback for a callback URL the manager
+// won't POST to (see SetCallbackHosts).
+var ErrCallbackNotAllowed = errors.New("callback URL not allowed")
+
+var jobsByStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "gateway_async_jobs",
+	Help: "Background jobs not yet finished, by status (queued or running).",
+}, []string{"status"})
+
+func init() {
+	prometheus.MustRegister(jobsByStatus)
+}
+
+// Status is where a job is in its lifecycle: queued -> running -> succeeded or failed.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"    // waiting for a free run slot
+	StatusRunning   Status = "running"   // its work is in progress
+	StatusSucceeded Status = "succeeded" // finished; Result is set
+	StatusFailed    Status = "failed"    // finished with an error; Result may still be set
+)
+
+// Job is a snapshot of one background job. It is also the body POSTed to the callback URL.
+type Job struct {
+	ID          string     `json:"id"`
+	Status      Status     `json:"status"`
+	CallbackURL string     `json:"callback_url"`
+	Result      any        `json:"result,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+
+	// Owner is who submitted the job, for checking who may look it up. It is never sent anywhere.
+	Owner string `json:"-"`
+
+	// CallbackError is set when the result couldn't be delivered to CallbackURL.
+	CallbackError string `json:"callback_error,omitempty"`
+}
+
+// Func is a job's work. ctx carries the values of the request that submitted it.
+type Func func(ctx context.Context) (any, error)
+
+// Manager runs submitted jobs in the background and keeps each one for lookup until an hour
+// after it finishes. It is bounded on every side: at most maxRunning jobs run at once, at most
+// maxQueued more wait for a turn (Submit fails beyond that), and each job's work gets timeout.
+//
+// Callback URLs are client input, so the manager only POSTs to public addresses unless
+// SetCallbackHosts lists the hosts it may call.
+type Manager struct {
+	mu            sync.Mutex
+	jobs          map[string]*Job
+	pending       int             // jobs queued or running
+	callbackHosts map[string]bool // nil means any public host
+	slots         chan struct{}
+	maxQueued     int
+	timeout       time.Duration
+	client        *http.Client
+	keep          time.Duration
+}
+
+// NewManager returns a manager running at most maxRunning jobs at once (at least 1) with up to
+// maxQueued more waiting. A job's work is cancelled after timeout; zero means no limit.
+func NewManager(maxRunning, maxQueued int, timeout time.Duration) *Manager {
+	if maxRunning < 1 {
+		maxRunning = 1
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	m := &Manager{
+		jobs:      make(map[string]*Job),
+		slots:     make(chan struct{}, maxRunning),
+		maxQueued: maxQueued,
+		timeout:   timeout,
+		keep:      time.Hour,
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: m.checkDial}
+	m.client = &http.Client{
+		Timeout: 10 * time.Second,
+		// No proxy: through one, checkDial would see the proxy's address rather than the callback's.
+		Transport: &http.Transport{Proxy: nil, DialContext: dialer.DialContext},
+		// A redirect would take the callback to a URL CheckCallback never saw.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	return m
+}
+
+// SetCallbackHosts restricts callbacks to the given hosts (names or IPs, matched against the
+// callback URL's hostname, case-insensitively), wherever they resolve to, so internal receivers
+// can be allowed explicitly. No hosts goes back to the default: any host, as long as it is
+// a literal public IP or resolves to one; loopback, private, link-local, multicast and
+// unspecified addresses are refused, also at dial time, so a name can't rebind to one.
+func (m *Manager) SetCallbackHosts(hosts ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(hosts) == 0 {
+		m.callbackHosts = nil
+		return
+	}
+	m.callbackHosts = make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		m.callbackHosts[strings.ToLower(host)] = true
+	}
+}
+
+// CheckCallback reports whether Submit would accept rawURL as a callback URL: an absolute
+// http(s) URL whose host is allowed (see SetCallbackHosts). Errors wrap ErrCallbackNotAllowed.
+func (m *Manager) CheckCallback(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("%w: must be an absolute http(s) URL", ErrCallbackNotAllowed)
+	}
+	host := strings.ToLower(u.Hostname())
+
+	m.mu.Lock()
+	allowed := m.callbackHosts
+	m.mu.Unlock()
+	if allowed != nil {
+		if !allowed[host] {
+			return fmt.Errorf("%w: host %q is not an allowed callback host", ErrCallbackNotAllowed, host)
+		}
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: host %q is not public", ErrCallbackNotAllowed, host)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !public(ip) {
+		return fmt.Errorf("%w: address %s is not public", ErrCallbackNotAllowed, ip)
+	}
+	return nil
+}
+
+// checkDial is the callback client's dialer Control: without an allowlist, it refuses to
+// connect to a non-public address whatever name resolved to it.
+func (m *Manager) checkDial(network, address string, _ syscall.RawConn) error {
+	m.mu.Lock()
+	allowlist := m.callbackHosts != nil
+	m.mu.Unlock()
+	if allowlist {
+		return nil
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrCallbackNotAllowed, err)
+	}
+	if ip := addrPort.Addr(); !public(ip) {
+		return fmt.Errorf("%w: address %s is not public", ErrCallbackNotAllowed, ip)
+	}
+	return nil
+}
+
+// public reports whether ip is an address a callback may go to.
+func public(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// Submit queues fn as a new job owned by owner and returns it straight away, or fails with
+// ErrQueueFull when the queue is full and ErrCallbackNotAllowed when callbackURL fails
+// CheckCallback. Once fn has run, the job is POSTed as JSON to callbackURL. ctx is only used
+// for its values: the job outlives the request that submitted it.
+func (m *Manager) Submit(ctx context.Context, owner, callbackURL string, fn Func) (Job, error) {
+	if err := m.CheckCallback(callbackURL); err != nil {
+		return Job{}, err
+	}
+	job := &Job{
+		ID:          newJobID(),
+		Status:      StatusQueued,
+		CallbackURL: callbackURL,
+		CreatedAt:   time.Now(),
+		Owner:       owner,
+	}
+	m.mu.Lock()
+	if pending := m.pending; pending >= cap(m.slots)+m.maxQueued {
+		m.mu.Unlock()
+		return Job{}, fmt.Errorf("%w: %d jobs queued or running", ErrQueueFull, pending)
+	}
+	m.pending++
+	m.jobs[job.ID] = job
+	snapshot := *job
+	m.mu.Unlock()
+	jobsByStatus.WithLabelValues(string(StatusQueued)).Inc()
+
+	go m.run(context.WithoutCancel(ctx), job, fn)
+	return snapshot, nil
+}
+
+// Get returns a snapshot of the job with the given id.
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// run waits for a run slot, runs fn, records the outcome and delivers it to the callback URL.
+func (m *Manager) run(ctx context.Context, job *Job, fn Func) {
+	m.slots <- struct{}{}
+	m.update(job, func(j *Job) { j.Status = StatusRunning })
+	jobsByStatus.WithLabelValues(string(StatusQueued)).Dec()
+	jobsByStatus.WithLabelValues(string(StatusRunning)).Inc()
+
+	result, err := m.work(ctx, fn)
+	<-m.slots
+	jobsByStatus.WithLabelValues(string(StatusRunning)).Dec()
+
+	finished := m.update(job, func(j *Job) {
+		m.pending--
+		now := time.Now()
+		j.Result = result
+		j.FinishedAt = &now
+		j.Status = StatusSucceeded
+		if err != nil {
+			j.Status = StatusFailed
+			j.Error = err.Error()
+		}
+	})
+
+	if err := m.deliver(ctx, finished); err != nil {
+		m.update(job, func(j *Job) { j.CallbackError = err.Error() })
+	}
+	time.AfterFunc(m.keep, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.jobs, job.ID)
+	})
+}
+
+// work runs fn under the manager's per-job timeout.
+func (m *Manager) work(ctx context.Context, fn Func) (any, error) {
+	if m.timeout <= 0 {
+		return fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+	return fn(ctx)
+}
+
+// update applies change to job under the lock and returns the resulting snapshot.
+func (m *Manager) update(job *Job, change func(*Job)) Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	change(job)
+	return *job
+}
+
+// deliver POSTs job to its callback URL, failing on any non-2xx answer.
+func (m *Manager) deliver(ctx context.Context, job Job) error {
+	body, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback answered %s", resp.Status)
+	}
+	return nil
+}
+
+// newJobID returns 16 random bytes, hex-encoded.
+func newJobID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
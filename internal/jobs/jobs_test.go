@@ -0,0 +1,197 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// newReceiver starts a callback receiver passing each job it's sent to got, and returns its URL.
+func newReceiver(t *testing.T, got chan<- Job) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var job Job
+		if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+			t.Errorf("callback body: %v", err)
+		}
+		got <- job
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestManagerRejectsJobsBeyondTheQueue(t *testing.T) {
+	got := make(chan Job, 2)
+	callback := newReceiver(t, got)
+	m := NewManager(1, 1, time.Minute)
+	u, _ := url.Parse(callback)
+	m.SetCallbackHosts(u.Hostname())
+
+	release := make(chan struct{})
+	work := func(ctx context.Context) (any, error) {
+		<-release
+		return "done", nil
+	}
+	running, err := m.Submit(context.Background(), "alice", callback, work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued, err := m.Submit(context.Background(), "alice", callback, work)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// One job running and one queued fill the manager.
+	if _, err := m.Submit(context.Background(), "alice", callback, work); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("third Submit returned %v, want %v", err, ErrQueueFull)
+	}
+	if job, _ := m.Get(queued.ID); job.Status != StatusQueued {
+		t.Fatalf("second job is %s, want %s", job.Status, StatusQueued)
+	}
+
+	// Both accepted jobs still run to completion and reach the callback.
+	close(release)
+	delivered := map[string]Job{}
+	for range 2 {
+		select {
+		case job := <-got:
+			delivered[job.ID] = job
+		case <-time.After(5 * time.Second):
+			t.Fatal("callback not received")
+		}
+	}
+	for _, id := range []string{running.ID, queued.ID} {
+		if job := delivered[id]; job.Status != StatusSucceeded || job.Result != "done" {
+			t.Fatalf("callback for %s = %+v, want it succeeded", id, job)
+		}
+	}
+	if job, ok := m.Get(running.ID); !ok || job.Status != StatusSucceeded || job.FinishedAt == nil {
+		t.Fatalf("Get(%s) = %+v, %v, want it succeeded", running.ID, job, ok)
+	}
+
+	// With the queue drained there is room again.
+	if _, err := m.Submit(context.Background(), "alice", callback, work); err != nil {
+		t.Fatalf("Submit after the jobs finished returned %v", err)
+	}
+}
+
+func TestManagerTimesOutJobs(t *testing.T) {
+	got := make(chan Job, 1)
+	callback := newReceiver(t, got)
+	m := NewManager(1, 0, 10*time.Millisecond)
+	u, _ := url.Parse(callback)
+	m.SetCallbackHosts(u.Hostname())
+
+	_, err := m.Submit(context.Background(), "alice", callback, func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case job := <-got:
+		if job.Status != StatusFailed || job.Error != context.DeadlineExceeded.Error() {
+			t.Fatalf("callback = %+v, want it failed with %v", job, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not received")
+	}
+}
+
+func TestCheckCallback(t *testing.T) {
+	m := NewManager(1, 0, 0)
+	for rawURL, ok := range map[string]bool{
+		"https://hooks.example.com/done": true,
+		"http://93.184.216.34/done":      true,
+		"/done":                          false,
+		"ftp://hooks.example.com/done":   false,
+		"http://localhost:8080/done":     false,
+		"http://api.localhost/done":      false,
+		"http://127.0.0.1/done":          false,
+		"http://10.0.0.5/done":           false,
+		"http://169.254.169.254/latest":  false,
+		"http://[::1]/done":              false,
+		"http://[::ffff:192.168.1.1]/":   false,
+	} {
+		if err := m.CheckCallback(rawURL); (err == nil) != ok {
+			t.Errorf("CheckCallback(%s) = %v, want allowed %v", rawURL, err, ok)
+		} else if err != nil && !errors.Is(err, ErrCallbackNotAllowed) {
+			t.Errorf("CheckCallback(%s) = %v, want %v", rawURL, err, ErrCallbackNotAllowed)
+		}
+	}
+
+	// An allowlist admits exactly its hosts, private or not.
+	m.SetCallbackHosts("Internal.svc", "10.0.0.5")
+	for rawURL, ok := range map[string]bool{
+		"http://internal.svc/done":       true,
+		"http://10.0.0.5/done":           true,
+		"https://hooks.example.com/done": false,
+	} {
+		if err := m.CheckCallback(rawURL); (err == nil) != ok {
+			t.Errorf("with an allowlist, CheckCallback(%s) = %v, want allowed %v", rawURL, err, ok)
+		}
+	}
+}
+
+func TestSubmitRefusesPrivateCallbacks(t *testing.T) {
+	got := make(chan Job, 1)
+	callback := newReceiver(t, got) // on 127.0.0.1, with no allowlist
+	m := NewManager(1, 0, 0)
+	if _, err := m.Submit(context.Background(), "alice", callback, func(ctx context.Context) (any, error) {
+		return nil, nil
+	}); !errors.Is(err, ErrCallbackNotAllowed) {
+		t.Fatalf("Submit(%s) returned %v, want %v", callback, err, ErrCallbackNotAllowed)
+	}
+}
+
+func TestCheckDialRefusesPrivateAddresses(t *testing.T) {
+	m := NewManager(1, 0, 0)
+	if err := m.checkDial("tcp", "127.0.0.1:80", nil); !errors.Is(err, ErrCallbackNotAllowed) {
+		t.Fatalf("dial to loopback returned %v, want %v", err, ErrCallbackNotAllowed)
+	}
+	if err := m.checkDial("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Fatalf("dial to a public address returned %v", err)
+	}
+	m.SetCallbackHosts("internal.svc")
+	if err := m.checkDial("tcp", "10.0.0.5:80", nil); err != nil {
+		t.Fatalf("with an allowlist, dial returned %v", err)
+	}
+}
+
+func TestCallbacksBypassTheProxy(t *testing.T) {
+	m := NewManager(1, 0, 0)
+	if proxy := m.client.Transport.(*http.Transport).Proxy; proxy != nil {
+		t.Fatal("callback client uses a proxy, want it to dial callbacks itself so checkDial sees their address")
+	}
+}
+
+func TestOwnerIsKeptButNotSent(t *testing.T) {
+	got := make(chan Job, 1)
+	callback := newReceiver(t, got)
+	m := NewManager(1, 0, 0)
+	u, _ := url.Parse(callback)
+	m.SetCallbackHosts(u.Hostname())
+
+	job, err := m.Submit(context.Background(), "alice", callback, func(ctx context.Context) (any, error) {
+		return "done", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job, _ := m.Get(job.ID); job.Owner != "alice" {
+		t.Fatalf("Owner = %q, want alice", job.Owner)
+	}
+	select {
+	case delivered := <-got:
+		if delivered.Owner != "" {
+			t.Fatalf("callback carried owner %q, want none", delivered.Owner)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not received")
+	}
+}
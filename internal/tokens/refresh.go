@@ -0,0 +1,102 @@
+// Package tokens issues, verifies and refreshes the gateway's bearer tokens. They are compatible
+// with the auth package's tokens and can also carry roles, scopes, a tenant and a client type;
+// a client can swap a token that is about to expire for a new one instead of authenticating
+// from scratch.
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Akshat-Kumar-work/pvt_go_package/pkg/auth"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// ErrTooEarly is returned by RefreshToken for a token that isn't yet within the refresh window of
+// its expiry, or that never expires.
+var ErrTooEarly = errors.New("token not yet within refresh window")
+
+// Refresher issues new tokens for ones close to expiry, signed by the same Service that verifies
+// them, so refreshed tokens are accepted wherever the old ones were.
+type Refresher struct {
+	svc    *Service
+	window time.Duration
+	grace  time.Duration
+}
+
+// NewRefresher returns a Refresher for tokens signed by svc. A token can be refreshed from window
+// before it expires until grace after it has expired; past that the client must authenticate again.
+func NewRefresher(svc *Service, window, grace time.Duration) *Refresher {
+	return &Refresher{svc: svc, window: window, grace: grace}
+}
+
+// RefreshToken verifies oldToken's signature and issues a new token with the same subject and
+// custom claims that expires after newTTL. The old token is revoked, so it can be refreshed only
+// once, and the Service needs a revocation store (see Service.SetRevocations). It fails with
+// auth.ErrInvalidToken for a token that doesn't verify, ErrTooEarly for one not yet within the
+// refresh window, auth.ErrExpiredToken for one that expired more than the grace period ago, and
+// ErrRevokedToken for one already refreshed or revoked.
+func (r *Refresher) RefreshToken(oldToken string, newTTL time.Duration) (string, error) {
+	// Expiry is checked below rather than by the parser, so a token just past it can still be refreshed.
+	claims, err := r.svc.verify(oldToken, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return "", err
+	}
+	if claims.ExpiresAt == nil {
+		return "", fmt.Errorf("%w: token never expires", ErrTooEarly)
+	}
+
+	left := claims.ExpiresAt.Sub(r.svc.now())
+	switch {
+	case left < -r.grace:
+		return "", auth.ErrExpiredToken
+	case left > r.window:
+		return "", fmt.Errorf("%w: expires in %s", ErrTooEarly, left.Round(time.Second))
+	}
+	return r.svc.rotate(claims, newTTL)
+}
+
+// SetRefreshGrace lets Service.RefreshToken refresh a token up to d after it has expired.
+// Zero, the default, only refreshes tokens that haven't.
+func (s *Service) SetRefreshGrace(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshFor = d
+}
+
+// RefreshToken verifies oldToken, which may have expired up to the refresh grace ago (see
+// SetRefreshGrace), and issues a new token with the same subject and custom claims that expires
+// after extend. The old token is revoked, so each token can be refreshed once: refreshing or using
+// it again fails with ErrRevokedToken. Refreshing therefore needs a revocation store (see
+// SetRevocations). It fails with auth.ErrExpiredToken for a token that expired longer ago, and
+// auth.ErrInvalidToken for one that doesn't verify or has no id.
+func (s *Service) RefreshToken(oldToken string, extend time.Duration) (string, error) {
+	// Expiry is checked below rather than by the parser, so a token just past it can still be refreshed.
+	claims, err := s.verify(oldToken, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return "", err
+	}
+	s.mu.RLock()
+	grace := s.refreshFor
+	s.mu.RUnlock()
+	if claims.ExpiresAt != nil && claims.ExpiresAt.Sub(s.now()) < -grace {
+		return "", auth.ErrExpiredToken
+	}
+	return s.rotate(claims, extend)
+}
+
+// rotate revokes the token claims came from and issues its replacement, expiring after ttl.
+// It fails with ErrRevokedToken if the token was already revoked, e.g. by a concurrent rotate,
+// and issues nothing if it can't be revoked.
+func (s *Service) rotate(claims *Claims, ttl time.Duration) (string, error) {
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+	if err := s.checkRevoked(claims); err != nil {
+		return "", err
+	}
+	if err := s.RevokeClaims(claims); err != nil {
+		return "", err
+	}
+	return s.issue(*claims, ttl)
+}
@@ -0,0 +1,139 @@
+package tokens
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/pvt_go_package/pkg/auth"
+)
+
+// newRefreshService returns a test Service that can revoke, so it can refresh, on the same clock.
+func newRefreshService(now *time.Time) *Service {
+	svc := newTestService(now)
+	store := NewMemoryRevocations()
+	store.now = svc.now
+	svc.SetRevocations(store, 0)
+	return svc
+}
+
+func TestServiceRefreshToken(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := newRefreshService(&now)
+	svc.SetRefreshGrace(time.Minute)
+	old, err := svc.CreateTokenWithCustomClaims("alice", time.Hour, map[string]any{"roles": []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(30 * time.Minute)
+	fresh, err := svc.RefreshToken(old, 2*time.Hour)
+	if err != nil {
+		t.Fatalf("RefreshToken() = %v", err)
+	}
+	claims, err := svc.VerifyToken(fresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "alice" || !claims.HasRole("admin") || !claims.ExpiresAt.Time.Equal(now.Add(2*time.Hour)) {
+		t.Fatalf("refreshed claims = %+v, want alice's with a 2h expiry", claims)
+	}
+
+	// The old token was used up: it neither refreshes nor verifies again.
+	if _, err := svc.RefreshToken(old, time.Hour); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("second RefreshToken() = %v, want %v", err, ErrRevokedToken)
+	}
+	if _, err := svc.VerifyToken(old); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("VerifyToken(old) = %v, want %v", err, ErrRevokedToken)
+	}
+}
+
+func TestServiceRefreshTokenGrace(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := newRefreshService(&now)
+	svc.SetRefreshGrace(time.Minute)
+
+	tests := []struct {
+		name    string
+		expired time.Duration // how long ago the token expired
+		wantErr error
+	}{
+		{"recently expired", 30 * time.Second, nil},
+		{"long expired", time.Hour, auth.ErrExpiredToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := svc.CreateTokenWithClaims("alice", nil, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			issued := now
+			now = now.Add(time.Minute + tt.expired)
+			defer func() { now = issued }()
+			if _, err := svc.RefreshToken(token, time.Hour); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RefreshToken() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServiceRefreshTokenNeedsRevocations(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := newTestService(&now)
+	token, err := svc.CreateTokenWithClaims("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh, err := svc.RefreshToken(token, time.Hour); err == nil {
+		t.Fatalf("RefreshToken() = %q without a revocation store, want an error", fresh)
+	}
+}
+
+func TestRefresherRefreshToken(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := newRefreshService(&now)
+	r := NewRefresher(svc, 5*time.Minute, time.Minute)
+
+	t.Run("near expiry", func(t *testing.T) {
+		old, err := svc.CreateTokenWithClaims("alice", []string{"admin"}, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.RefreshToken(old, time.Hour); !errors.Is(err, ErrTooEarly) {
+			t.Fatalf("RefreshToken() an hour from expiry = %v, want %v", err, ErrTooEarly)
+		}
+
+		issued := now
+		now = now.Add(57 * time.Minute)
+		defer func() { now = issued }()
+		fresh, err := r.RefreshToken(old, time.Hour)
+		if err != nil {
+			t.Fatalf("RefreshToken() 3m from expiry = %v", err)
+		}
+		claims, err := svc.VerifyToken(fresh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.Subject != "alice" || !claims.HasRole("admin") || !claims.ExpiresAt.Time.Equal(now.Add(time.Hour)) {
+			t.Fatalf("refreshed claims = %+v, want alice's with a 1h expiry", claims)
+		}
+
+		// A second refresh of the same token is refused, so one token can't mint many.
+		if _, err := r.RefreshToken(old, time.Hour); !errors.Is(err, ErrRevokedToken) {
+			t.Fatalf("second RefreshToken() = %v, want %v", err, ErrRevokedToken)
+		}
+	})
+
+	t.Run("long expired", func(t *testing.T) {
+		old, err := svc.CreateTokenWithClaims("alice", nil, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		issued := now
+		now = now.Add(2 * time.Hour)
+		defer func() { now = issued }()
+		if _, err := r.RefreshToken(old, time.Hour); !errors.Is(err, auth.ErrExpiredToken) {
+			t.Fatalf("RefreshToken() an hour after expiry = %v, want %v", err, auth.ErrExpiredToken)
+		}
+	})
+}
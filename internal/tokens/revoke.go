@@ -0,0 +1,120 @@
+package tokens
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Akshat-Kumar-work/pvt_go_package/pkg/auth"
+)
+
+// ErrRevokedToken is returned by VerifyToken for a token whose id was revoked. It wraps
+// auth.ErrInvalidToken, so callers treating every bad token alike need no change.
+var ErrRevokedToken = fmt.Errorf("%w: token revoked", auth.ErrInvalidToken)
+
+// RevocationStore records the ids (jti) of revoked tokens. NewMemoryRevocations is the in-memory
+// one; a shared store (e.g. Redis) lets every gateway instance see the same revocations.
+type RevocationStore interface {
+	// Revoke records jti as revoked until until, when the token has expired anyway and the entry
+	// may be dropped. A zero until keeps it for good.
+	Revoke(jti string, until time.Time) error
+	// Revoked reports whether jti is revoked.
+	Revoked(jti string) (bool, error)
+}
+
+// MemoryRevocations is a RevocationStore in memory, for a single gateway instance.
+// Entries are purged once their token has expired. It is safe for concurrent use.
+type MemoryRevocations struct {
+	mu      sync.Mutex
+	entries map[string]time.Time // jti -> until
+	now     func() time.Time
+}
+
+// NewMemoryRevocations returns an empty MemoryRevocations.
+func NewMemoryRevocations() *MemoryRevocations {
+	return &MemoryRevocations{entries: make(map[string]time.Time), now: time.Now}
+}
+
+// Revoke records jti as revoked until until, purging the entries that have expired.
+func (m *MemoryRevocations) Revoke(jti string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	for id, u := range m.entries {
+		if !u.IsZero() && !now.Before(u) {
+			delete(m.entries, id)
+		}
+	}
+	m.entries[jti] = until
+	return nil
+}
+
+// Revoked reports whether jti was revoked and the entry hasn't expired.
+func (m *MemoryRevocations) Revoked(jti string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	until, ok := m.entries[jti]
+	return ok && (until.IsZero() || m.now().Before(until)), nil
+}
+
+// SetRevocations makes VerifyToken reject tokens whose id store holds. keep is how long Revoke
+// remembers an id, which should cover the longest-lived token the service issues; zero is for good.
+// A nil store turns revocation off.
+func (s *Service) SetRevocations(store RevocationStore, keep time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revocations, s.revokeFor = store, keep
+}
+
+// Revoke revokes the token with id jti (see Claims.ID) for as long as SetRevocations keeps ids.
+func (s *Service) Revoke(jti string) error {
+	s.mu.RLock()
+	store, keep := s.revocations, s.revokeFor
+	s.mu.RUnlock()
+	if store == nil {
+		return fmt.Errorf("revoking token %s: no revocation store", jti)
+	}
+	var until time.Time
+	if keep > 0 {
+		until = s.now().Add(keep)
+	}
+	return store.Revoke(jti, until)
+}
+
+// RevokeClaims revokes the token claims came from until it expires, the earliest its entry can go.
+func (s *Service) RevokeClaims(claims *Claims) error {
+	s.mu.RLock()
+	store := s.revocations
+	s.mu.RUnlock()
+	if store == nil {
+		return fmt.Errorf("revoking token %s: no revocation store", claims.ID)
+	}
+	if claims.ID == "" {
+		return fmt.Errorf("%w: token has no id", auth.ErrInvalidToken)
+	}
+	var until time.Time
+	if claims.ExpiresAt != nil {
+		until = claims.ExpiresAt.Time
+	}
+	return store.Revoke(claims.ID, until)
+}
+
+// checkRevoked fails with ErrRevokedToken if claims' token was revoked. Tokens without an id,
+// issued before ids were, can't be revoked.
+func (s *Service) checkRevoked(claims *Claims) error {
+	s.mu.RLock()
+	store := s.revocations
+	s.mu.RUnlock()
+	if store == nil || claims.ID == "" {
+		return nil
+	}
+	revoked, err := store.Revoked(claims.ID)
+	if err != nil {
+		// Fail closed: a token that can't be checked isn't trusted.
+		return fmt.Errorf("%w: checking revocation: %w", auth.ErrInvalidToken, err)
+	}
+	if revoked {
+		return ErrRevokedToken
+	}
+	return nil
+}
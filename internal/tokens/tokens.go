@@ -0,0 +1,208 @@
+package tokens
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Akshat-Kumar-work/pvt_go_package/pkg/auth"
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// ErrInvalidClaims is returned by CreateTokenWithCustomClaims for a custom claim it doesn't know,
+// one of the registered claims, or a value of the wrong type.
+var ErrInvalidClaims = errors.New("invalid custom claims")
+
+// ErrUnknownKey is returned by SetKeys when the signing key id isn't in the key set.
+var ErrUnknownKey = errors.New("unknown signing key id")
+
+// Claims are the claims of the gateway's bearer tokens: the registered claims auth.Claims carries
+// plus the roles and scopes the caller holds, its tenant, its tier and the kind of client it is.
+// All are optional, so tokens issued by auth.Service verify as Claims with none, and tokens with
+// them verify with auth.Service.
+type Claims struct {
+	jwt.RegisteredClaims
+	Roles      []string `json:"roles,omitempty"`
+	Scopes     []string `json:"scopes,omitempty"`
+	TenantID   string   `json:"tenant_id,omitempty"`
+	Tier       string   `json:"tier,omitempty"` // e.g. "premium"
+	ClientType string   `json:"client_type,omitempty"`
+}
+
+// registeredClaims are the JWT claim names the Service sets itself.
+var registeredClaims = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// HasRole reports whether the claims include role.
+func (c *Claims) HasRole(role string) bool {
+	return slices.Contains(c.Roles, role)
+}
+
+// Service creates and verifies HMAC-SHA256 JWTs like auth.Service, with roles.
+//
+// It is a fork of auth.Service's verification, not a wrapper around it: the gateway used to verify
+// with auth.Service.VerifyToken, whose auth.Claims can't carry roles, and Service replaced it.
+// It keeps auth.Service's token format and error sentinels (auth.ErrInvalidToken,
+// auth.ErrExpiredToken, auth.ErrEmptySecret, auth.ErrEmptySubject), so tokens from either verify
+// with the other given the same secret. Fixes to auth.Service's verification don't reach the
+// gateway on their own; they need porting here.
+//
+// It holds a set of keys by key id, so keys can be rotated without downtime: new tokens are signed
+// with the current key and carry its id in the kid header, and a token is verified with the key
+// its kid names, so tokens signed with an older key in the set keep working until they expire.
+// The key with the empty id verifies tokens without a kid, such as those auth.Service issues.
+type Service struct {
+	now func() time.Time
+
+	mu          sync.RWMutex
+	keys        map[string][]byte // kid -> secret
+	current     string            // kid new tokens are signed with
+	leeway      time.Duration
+	revocations RevocationStore // nil means tokens can't be revoked
+	revokeFor   time.Duration
+	refreshFor  time.Duration // how long past expiry RefreshToken still accepts a token
+
+	rotateMu sync.Mutex // serializes rotate, so a token is only ever rotated once
+}
+
+// NewService returns a Service signing with secret, the same secret auth.Service would be given.
+// The secret has the empty key id, so tokens carry no kid until SetKeys rotates keys in.
+func NewService(secret []byte) *Service {
+	return &Service{now: time.Now, keys: map[string][]byte{"": secret}}
+}
+
+// SetKeys replaces the key set with keys (kid -> secret) and signs new tokens with the key
+// current names from now on. Tokens are only verified with keys in the set, so keep a retired key
+// in it until the tokens it signed have expired. It fails with ErrUnknownKey if keys has no current.
+func (s *Service) SetKeys(keys map[string][]byte, current string) error {
+	if _, ok := keys[current]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownKey, current)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = maps.Clone(keys)
+	s.current = current
+	return nil
+}
+
+// SetLeeway lets VerifyToken accept a token up to d past its expiry or before its not-before time,
+// for tokens minted on machines whose clocks are slightly off. Zero, the default, is strict.
+func (s *Service) SetLeeway(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.leeway = d
+}
+
+// CreateTokenWithClaims creates a signed JWT for subject holding roles, which may be empty.
+// It expires after ttl; zero means never.
+func (s *Service) CreateTokenWithClaims(subject string, roles []string, ttl time.Duration) (string, error) {
+	return s.issue(Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: subject},
+		Roles:            roles,
+	}, ttl)
+}
+
+// CreateTokenWithCustomClaims creates a signed JWT for subject carrying custom, which holds Claims'
+// custom fields by their JSON names ("roles", "scopes", "tenant_id", "tier", "client_type");
+// VerifyToken returns them typed. It expires after ttl; zero means never. It fails with ErrInvalidClaims for
+// any other name, a registered claim such as "sub" or "exp", or a value of the wrong type.
+func (s *Service) CreateTokenWithCustomClaims(subject string, ttl time.Duration, custom map[string]any) (string, error) {
+	for _, name := range registeredClaims {
+		if _, ok := custom[name]; ok {
+			return "", fmt.Errorf("%w: %q is set by the service", ErrInvalidClaims, name)
+		}
+	}
+	raw, err := json.Marshal(custom)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidClaims, err)
+	}
+	var claims Claims
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&claims); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidClaims, err)
+	}
+	claims.Subject = subject
+	return s.issue(claims, ttl)
+}
+
+// VerifyToken verifies the token's signature and expiry, give or take the leeway (see SetLeeway),
+// and that it hasn't been revoked (see SetRevocations), and returns its claims. It fails with
+// auth.ErrExpiredToken for an expired token, ErrRevokedToken for a revoked one and
+// auth.ErrInvalidToken for any other bad one.
+func (s *Service) VerifyToken(token string) (*Claims, error) {
+	return s.verify(token)
+}
+
+// issue signs claims with the current key, a fresh id and issue time, and an expiry ttl from now
+// (none for zero).
+func (s *Service) issue(claims Claims, ttl time.Duration) (string, error) {
+	s.mu.RLock()
+	kid, secret := s.current, s.keys[s.current]
+	s.mu.RUnlock()
+	if len(secret) == 0 {
+		return "", auth.ErrEmptySecret
+	}
+	if strings.TrimSpace(claims.Subject) == "" {
+		return "", auth.ErrEmptySubject
+	}
+
+	now := s.now()
+	claims.ID = newTokenID()
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = nil
+	if ttl > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	return token.SignedString(secret)
+}
+
+// verify parses token as HS256-signed Claims, checking it with the key its kid names and against
+// the revocations, with extra parser options.
+func (s *Service) verify(token string, opts ...jwt.ParserOption) (*Claims, error) {
+	s.mu.RLock()
+	keys, current, leeway := s.keys, s.current, s.leeway
+	s.mu.RUnlock()
+	if len(keys[current]) == 0 {
+		return nil, auth.ErrEmptySecret
+	}
+
+	claims := &Claims{}
+	opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithTimeFunc(s.now), jwt.WithLeeway(leeway))
+	_, err := jwt.ParseWithClaims(strings.TrimSpace(token), claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		secret, ok := keys[kid]
+		if !ok || len(secret) == 0 {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownKey, kid)
+		}
+		return secret, nil
+	}, opts...)
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, auth.ErrExpiredToken
+	case err != nil:
+		return nil, auth.ErrInvalidToken
+	}
+	if err := s.checkRevoked(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// newTokenID returns 16 random bytes, hex-encoded.
+func newTokenID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
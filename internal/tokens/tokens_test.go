@@ -0,0 +1,243 @@
+package tokens
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Akshat-Kumar-work/pvt_go_package/pkg/auth"
+)
+
+// newTestService returns a Service whose clock reads *now.
+func newTestService(now *time.Time) *Service {
+	svc := NewService([]byte("test-secret"))
+	svc.now = func() time.Time { return *now }
+	return svc
+}
+
+func TestVerifyTokenLeeway(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := newTestService(&now)
+	token, err := svc.CreateTokenWithClaims("alice", nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		leeway  time.Duration
+		expired time.Duration // how long ago the token expired
+		wantErr error
+	}{
+		{"strict rejects a just-expired token", 0, 10 * time.Second, auth.ErrExpiredToken},
+		{"leeway covers a token expired by less", 30 * time.Second, 10 * time.Second, nil},
+		{"leeway doesn't cover a token expired by more", 30 * time.Second, 60 * time.Second, auth.ErrExpiredToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc.SetLeeway(tt.leeway)
+			now = time.Date(2026, 1, 1, 12, 1, 0, 0, time.UTC).Add(tt.expired)
+			_, err := svc.VerifyToken(token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := newTestService(&now)
+	if err := svc.SetKeys(map[string][]byte{"old": []byte("old-secret")}, "old"); err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := svc.CreateTokenWithClaims("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.SetKeys(map[string][]byte{"old": []byte("old-secret"), "new": []byte("new-secret")}, "new"); err != nil {
+		t.Fatal(err)
+	}
+	newToken, err := svc.CreateTokenWithClaims("bob", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for token, want := range map[string]string{oldToken: "alice", newToken: "bob"} {
+		claims, err := svc.VerifyToken(token)
+		if err != nil {
+			t.Fatalf("VerifyToken() error = %v", err)
+		}
+		if claims.Subject != want {
+			t.Fatalf("subject = %q, want %q", claims.Subject, want)
+		}
+	}
+
+	// Once the old key is retired, its tokens stop verifying.
+	if err := svc.SetKeys(map[string][]byte{"new": []byte("new-secret")}, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.VerifyToken(oldToken); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("VerifyToken(old) error = %v, want %v", err, auth.ErrInvalidToken)
+	}
+	if err := svc.SetKeys(map[string][]byte{"new": []byte("new-secret")}, "missing"); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("SetKeys() error = %v, want %v", err, ErrUnknownKey)
+	}
+}
+
+func TestKeylessTokensUseTheEmptyKeyID(t *testing.T) {
+	legacy, err := auth.NewService([]byte("legacy-secret")).CreateToken("alice", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService([]byte("legacy-secret"))
+	if err := svc.SetKeys(map[string][]byte{"": []byte("legacy-secret"), "k1": []byte("new-secret")}, "k1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.VerifyToken(legacy); err != nil {
+		t.Fatalf("VerifyToken(legacy) error = %v", err)
+	}
+}
+
+func TestCustomClaimsRoundTrip(t *testing.T) {
+	svc := NewService([]byte("test-secret"))
+	token, err := svc.CreateTokenWithCustomClaims("alice", time.Hour, map[string]any{
+		"roles":     []string{"admin"},
+		"scopes":    []string{"orders:read", "orders:write"},
+		"tenant_id": "acme",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := svc.VerifyToken(token)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	if claims.Subject != "alice" || !claims.HasRole("admin") || claims.TenantID != "acme" ||
+		len(claims.Scopes) != 2 || claims.Scopes[1] != "orders:write" {
+		t.Fatalf("claims = %+v, want the custom claims back", claims)
+	}
+}
+
+func TestCustomClaimsCantBeForged(t *testing.T) {
+	svc := NewService([]byte("test-secret"))
+	token, err := svc.CreateTokenWithCustomClaims("alice", time.Hour, map[string]any{"tenant_id": "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Swap the payload for one claiming another tenant, keeping the original signature.
+	parts := strings.Split(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts[1] = base64.RawURLEncoding.EncodeToString(bytes.Replace(payload, []byte(`"acme"`), []byte(`"evil"`), 1))
+	if _, err := svc.VerifyToken(strings.Join(parts, ".")); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("VerifyToken(forged) error = %v, want %v", err, auth.ErrInvalidToken)
+	}
+
+	// Custom claims can't stand in for the registered ones, or smuggle in unknown ones.
+	for _, custom := range []map[string]any{
+		{"sub": "bob"},
+		{"exp": 0},
+		{"is_admin": true},
+		{"roles": "admin"},
+	} {
+		if _, err := svc.CreateTokenWithCustomClaims("alice", time.Hour, custom); !errors.Is(err, ErrInvalidClaims) {
+			t.Fatalf("CreateTokenWithCustomClaims(%v) error = %v, want %v", custom, err, ErrInvalidClaims)
+		}
+	}
+}
+
+func TestRevokedTokenFailsVerification(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := newTestService(&now)
+	store := NewMemoryRevocations()
+	store.now = func() time.Time { return now }
+	svc.SetRevocations(store, time.Hour)
+
+	token, err := svc.CreateTokenWithClaims("alice", nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := svc.VerifyToken(token)
+	if err != nil {
+		t.Fatalf("VerifyToken() error = %v", err)
+	}
+	if claims.ID == "" {
+		t.Fatal("token has no jti")
+	}
+
+	if err := svc.Revoke(claims.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.VerifyToken(token); !errors.Is(err, ErrRevokedToken) || !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("VerifyToken(revoked) error = %v, want %v", err, ErrRevokedToken)
+	}
+
+	// Other tokens are unaffected, and refreshing doesn't revive the revoked one.
+	other, _ := svc.CreateTokenWithClaims("alice", nil, time.Hour)
+	if _, err := svc.VerifyToken(other); err != nil {
+		t.Fatalf("VerifyToken(other) error = %v", err)
+	}
+	if _, err := NewRefresher(svc, 2*time.Hour, 0).RefreshToken(token, time.Hour); !errors.Is(err, ErrRevokedToken) {
+		t.Fatalf("RefreshToken(revoked) error = %v, want %v", err, ErrRevokedToken)
+	}
+}
+
+func TestMemoryRevocationsPurgesExpiredEntries(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryRevocations()
+	store.now = func() time.Time { return now }
+
+	store.Revoke("short", now.Add(time.Minute))
+	store.Revoke("forever", time.Time{})
+	now = now.Add(2 * time.Minute)
+	if revoked, _ := store.Revoked("short"); revoked {
+		t.Fatal("expired entry still revoked")
+	}
+
+	store.Revoke("new", now.Add(time.Minute))
+	if n := len(store.entries); n != 2 {
+		t.Fatalf("%d entries after purge, want 2", n)
+	}
+	if revoked, _ := store.Revoked("forever"); !revoked {
+		t.Fatal("entry without expiry purged")
+	}
+}
+
+func TestInterchangeableWithAuthService(t *testing.T) {
+	secret := []byte("shared-secret")
+	legacy := auth.NewService(secret)
+	svc := NewService(secret)
+
+	token, err := legacy.CreateToken("alice", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := svc.VerifyToken(token); err != nil || claims.Subject != "alice" {
+		t.Fatalf("VerifyToken(auth.Service token) = %v, %v", claims, err)
+	}
+
+	token, err = svc.CreateTokenWithClaims("bob", []string{"admin"}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims, err := legacy.VerifyToken(token); err != nil || claims.Subject != "bob" {
+		t.Fatalf("auth.Service.VerifyToken(token) = %v, %v", claims, err)
+	}
+
+	if _, err := svc.VerifyToken("not-a-token"); !errors.Is(err, auth.ErrInvalidToken) {
+		t.Fatalf("VerifyToken(garbage) error = %v, want %v", err, auth.ErrInvalidToken)
+	}
+	if _, err := NewService(nil).VerifyToken(token); !errors.Is(err, auth.ErrEmptySecret) {
+		t.Fatalf("VerifyToken() with no secret error = %v, want %v", err, auth.ErrEmptySecret)
+	}
+}
@@ -0,0 +1,64 @@
+// Package version describes the running build. Version, Commit and BuildTime are set at build
+// time with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/version.Version=v1.2.0 \
+//	  -X github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/Akshat-Kumar-work/concurrent-api-gateway/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+//	  ./cmd/concurrent-api-gateway
+//
+// (see the makefile's build target).
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X. When they aren't, Get falls back to the module version and VCS revision
+// the go command stamps into the binary, and then to "dev" / "unknown". Build time has no such fallback.
+var (
+	Version   = ""
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info is what /version reports about the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the running binary's build details.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.modified" && setting.Value == "true" && info.Commit != "" && Commit == "":
+				info.Commit += "-dirty"
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
@@ -0,0 +1,17 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGetDefaultsWithoutLdflags(t *testing.T) {
+	// A test binary carries no VCS stamp, so nothing stands in for the -ldflags values.
+	info := Get()
+	if info.Version != "dev" || info.Commit != "unknown" || info.BuildTime != "unknown" {
+		t.Fatalf("Get() = %+v, want dev / unknown / unknown", info)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Fatalf("GoVersion = %q, want %q", info.GoVersion, runtime.Version())
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the downstream while a breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// ErrSlowStart is returned without calling the downstream for calls a recently closed breaker
+// sheds while ramping traffic back up (see Breaker.SetSlowStart). It wraps ErrCircuitOpen.
+var ErrSlowStart = fmt.Errorf("%w: slow start", ErrCircuitOpen)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState string
+
+const (
+	StateClosed   BreakerState = "closed"    // calls go through; failures are counted
+	StateOpen     BreakerState = "open"      // calls fail fast with ErrCircuitOpen
+	StateHalfOpen BreakerState = "half-open" // one probe call is let through to test recovery
+)
+
+// Breaker is a circuit breaker for one downstream service.
+//
+// It trips to open after failureThreshold consecutive failures. Once openDuration has
+// passed it lets a single probe through (half-open): success closes it again, failure
+// re-opens it for another openDuration.
+//
+// A probe can be given a shorter timeout than ordinary calls (see SetProbeTimeout), so a
+// service that is still hanging keeps the breaker open without holding a caller for long.
+//
+// With a slow-start window set, a breaker that has just closed again doesn't let full traffic
+// through at once: the share of calls allowed grows linearly from 0 to 100% over the window.
+type Breaker struct {
+	mu               sync.Mutex
+	failureThreshold int
+	openDuration     time.Duration
+	state            BreakerState
+	failures         int       // consecutive failures while closed
+	openedAt         time.Time // when the breaker last opened
+	probing          bool      // a half-open probe is in flight
+	probeTimeout     time.Duration
+	slowStart        time.Duration
+	closedAt         time.Time // when the breaker last closed after being open
+	now              func() time.Time
+	rand             func() float64
+}
+
+// NewBreaker returns a closed breaker that opens after failureThreshold consecutive
+// failures and stays open for openDuration before probing.
+func NewBreaker(failureThreshold int, openDuration time.Duration) *Breaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &Breaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		state:            StateClosed,
+		now:              time.Now,
+		rand:             rand.Float64,
+	}
+}
+
+// SetSlowStart sets how long the breaker takes to ramp back to full traffic after a successful
+// probe closes it. Zero (the default) lets full traffic through as soon as it closes.
+func (b *Breaker) SetSlowStart(window time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.slowStart = window
+}
+
+// SetProbeTimeout sets the timeout half-open probes run under, on top of the caller's deadline.
+// Zero (the default) gives probes the same time as any other call.
+func (b *Breaker) SetProbeTimeout(timeout time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probeTimeout = timeout
+}
+
+// Wrap returns a Fetcher that goes through the breaker before calling fetcher. Calls redirected
+// to another host with WithUpstream bypass it.
+func (b *Breaker) Wrap(fetcher Fetcher) Fetcher {
+	return func(ctx context.Context, userID string) (any, error) {
+		if Upstream(ctx) != nil {
+			// Not the service the breaker guards (see WithUpstream).
+			return fetcher(ctx, userID)
+		}
+		probe, err := b.allow()
+		if err != nil {
+			return nil, err
+		}
+		if probe {
+			b.mu.Lock()
+			timeout := b.probeTimeout
+			b.mu.Unlock()
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+		}
+		data, err := fetcher(ctx, userID)
+		b.record(err)
+		return data, err
+	}
+}
+
+// State returns the breaker's current state, moving open to half-open if openDuration has passed.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// allow returns nil if a call may go through, claiming the probe slot when half-open;
+// probe reports whether it did.
+func (b *Breaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+
+	switch b.state {
+	case StateOpen:
+		return false, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probing {
+			return false, ErrCircuitOpen
+		}
+		b.probing = true
+		return true, nil
+	case StateClosed:
+		if b.rand() >= b.allowedShare() {
+			return false, ErrSlowStart
+		}
+	}
+	return false, nil
+}
+
+// allowedShare returns the fraction of calls a closed breaker lets through: 1 outside the
+// slow-start window, rising linearly from 0 inside it. Caller must hold b.mu.
+func (b *Breaker) allowedShare() float64 {
+	if b.slowStart <= 0 || b.closedAt.IsZero() {
+		return 1
+	}
+	elapsed := b.now().Sub(b.closedAt)
+	if elapsed >= b.slowStart {
+		return 1
+	}
+	return float64(elapsed) / float64(b.slowStart)
+}
+
+// record updates the breaker with the outcome of a call that allow let through.
+// A call cancelled by our own caller, or one that never got an outbound slot or a place in the
+// service's bulkhead, says nothing about the downstream, so it only frees the probe slot.
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasProbe := b.probing
+	b.probing = false
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrConcurrencyTimeout) || errors.Is(err, ErrBulkheadFull) {
+		return
+	}
+
+	switch {
+	case err == nil:
+		if b.state != StateClosed {
+			b.closedAt = b.now()
+		}
+		b.state = StateClosed
+		b.failures = 0
+	case wasProbe:
+		b.open()
+	default:
+		b.failures++
+		if b.failures >= b.failureThreshold {
+			b.open()
+		}
+	}
+}
+
+// open trips the breaker. Caller must hold b.mu.
+func (b *Breaker) open() {
+	b.state = StateOpen
+	b.openedAt = b.now()
+	b.failures = 0
+	b.closedAt = time.Time{}
+}
+
+// refresh moves an open breaker to half-open once openDuration has passed. Caller must hold b.mu.
+func (b *Breaker) refresh() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.openDuration {
+		b.state = StateHalfOpen
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker whose clock reads *now.
+func newTestBreaker(threshold int, openFor time.Duration, now *time.Time) *Breaker {
+	b := NewBreaker(threshold, openFor)
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestBreakerTripsFailsFastAndRecovers(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(3, 30*time.Second, &now)
+
+	calls := 0
+	failing := true
+	fetch := b.Wrap(func(ctx context.Context, userID string) (any, error) {
+		calls++
+		if failing {
+			return nil, errors.New("boom")
+		}
+		return userID, nil
+	})
+
+	for range 3 {
+		fetch(context.Background(), "123")
+	}
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("state after 3 failures = %s, want %s", got, StateOpen)
+	}
+
+	// Open: fail fast without calling the downstream.
+	if _, err := fetch(context.Background(), "123"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("open breaker returned %v, want %v", err, ErrCircuitOpen)
+	}
+	if calls != 3 {
+		t.Fatalf("downstream called %d times, want 3", calls)
+	}
+
+	// After openDuration a failed probe re-opens it...
+	now = now.Add(30 * time.Second)
+	if got := b.State(); got != StateHalfOpen {
+		t.Fatalf("state after openDuration = %s, want %s", got, StateHalfOpen)
+	}
+	fetch(context.Background(), "123")
+	if got := b.State(); got != StateOpen {
+		t.Fatalf("state after a failed probe = %s, want %s", got, StateOpen)
+	}
+
+	// ...and a successful one closes it.
+	now = now.Add(30 * time.Second)
+	failing = false
+	if _, err := fetch(context.Background(), "123"); err != nil {
+		t.Fatalf("probe returned %v", err)
+	}
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("state after a successful probe = %s, want %s", got, StateClosed)
+	}
+}
+
+func TestBreakerLetsOneProbeThrough(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(1, time.Second, &now)
+	b.record(errors.New("boom"))
+	now = now.Add(time.Second)
+
+	if _, err := b.allow(); err != nil {
+		t.Fatalf("first half-open call: %v", err)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second half-open call: %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestBreakerIgnoresCallerCancellation(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(1, time.Second, &now)
+	fetch := b.Wrap(func(ctx context.Context, userID string) (any, error) {
+		return nil, context.Canceled
+	})
+
+	fetch(context.Background(), "123")
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("state after a cancelled call = %s, want %s", got, StateClosed)
+	}
+}
+
+func TestBreakerProbesUnderTheProbeTimeout(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(1, time.Second, &now)
+	b.SetProbeTimeout(50 * time.Millisecond)
+
+	var left time.Duration
+	var hasDeadline bool
+	fetch := b.Wrap(func(ctx context.Context, userID string) (any, error) {
+		var deadline time.Time
+		deadline, hasDeadline = ctx.Deadline()
+		left = time.Until(deadline)
+		return nil, errors.New("boom")
+	})
+
+	// Calls while closed keep the caller's context as is...
+	fetch(context.Background(), "123")
+	if hasDeadline {
+		t.Fatalf("call while closed had a deadline, want none")
+	}
+
+	// ...while the half-open probe gets the probe timeout.
+	now = now.Add(time.Second)
+	fetch(context.Background(), "123")
+	if !hasDeadline || left <= 0 || left > 50*time.Millisecond {
+		t.Fatalf("probe had %s left (deadline set: %v), want at most %s", left, hasDeadline, 50*time.Millisecond)
+	}
+}
+
+func TestBreakerRampsTrafficUpAfterClosing(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := newTestBreaker(1, time.Second, &now)
+	b.SetSlowStart(10 * time.Second)
+
+	// Count how many of 100 evenly spread draws each point of the window lets through.
+	draw := 0
+	b.rand = func() float64 { draw++; return float64(draw%100) / 100 }
+	allowed := func() int {
+		n := 0
+		for range 100 {
+			if _, err := b.allow(); err == nil {
+				n++
+			} else if !errors.Is(err, ErrSlowStart) {
+				t.Fatalf("closed breaker returned %v, want nil or %v", err, ErrSlowStart)
+			}
+		}
+		return n
+	}
+
+	if got := allowed(); got != 100 {
+		t.Fatalf("calls allowed before the breaker ever opened = %d, want 100", got)
+	}
+
+	b.record(errors.New("boom"))
+	now = now.Add(time.Second)
+	if _, err := b.allow(); err != nil {
+		t.Fatalf("half-open probe: %v", err)
+	}
+	b.record(nil)
+	if got := b.State(); got != StateClosed {
+		t.Fatalf("state after a successful probe = %s, want %s", got, StateClosed)
+	}
+
+	for _, tc := range []struct {
+		after time.Duration
+		want  int
+	}{
+		{0, 0},
+		{2500 * time.Millisecond, 25},
+		{5 * time.Second, 50},
+		{7500 * time.Millisecond, 75},
+		{10 * time.Second, 100},
+	} {
+		now = now.Add(tc.after - now.Sub(b.closedAt))
+		if got := allowed(); got != tc.want {
+			t.Errorf("calls allowed %s after closing = %d, want %d", tc.after, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// BreakerGrouping is what a circuit breaker guards: one service, or every service on a host.
+type BreakerGrouping string
+
+const (
+	BreakerPerService BreakerGrouping = "service" // each service trips on its own failures
+	BreakerPerHost    BreakerGrouping = "host"    // services sharing a host trip together
+)
+
+var (
+	breakersMu   sync.RWMutex
+	breakers     = make(map[string]*Breaker)
+	grouping     = BreakerPerService
+	hostBreakers = make(map[string]*Breaker) // host -> breaker shared by its services
+)
+
+// newDefaultBreaker returns a breaker with the default services' settings: 5 consecutive failures
+// open it for 30s, and once it closes again traffic ramps back up over 10s.
+func newDefaultBreaker() *Breaker {
+	b := NewBreaker(5, 30*time.Second)
+	b.SetSlowStart(10 * time.Second)
+	return b
+}
+
+// SetBreakerGrouping sets what the registered breakers guard (see RegisterBreaker and
+// WrapBreaker). With BreakerPerHost, the services whose BaseURL (see FetchConfig) has the same
+// host share one breaker, with the default services' settings, instead of using their own: when
+// the host goes down the first failures trip it for all of them, and they all fail fast together.
+// The default is BreakerPerService.
+func SetBreakerGrouping(g BreakerGrouping) {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	grouping = g
+}
+
+// WrapBreaker returns a Fetcher that goes through the named service's registered breaker, or the
+// one its host shares (see SetBreakerGrouping), before calling fetcher. The breaker is looked up at
+// every call, so the grouping and the service's BaseURL can change at runtime. A service without
+// a registered breaker calls fetcher directly.
+func WrapBreaker(name string, fetcher Fetcher) Fetcher {
+	return func(ctx context.Context, userID string) (any, error) {
+		b, ok := activeBreaker(name)
+		if !ok {
+			return fetcher(ctx, userID)
+		}
+		return b.Wrap(fetcher)(ctx, userID)
+	}
+}
+
+// activeBreaker returns the breaker the named service's calls go through under the current grouping.
+func activeBreaker(name string) (*Breaker, bool) {
+	breakersMu.RLock()
+	b, ok := breakers[name]
+	perHost := grouping == BreakerPerHost
+	breakersMu.RUnlock()
+	if !ok || !perHost {
+		return b, ok
+	}
+
+	base := fetchConfig(name).BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return b, true
+	}
+
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	shared, ok := hostBreakers[u.Host]
+	if !ok {
+		shared = newDefaultBreaker()
+		hostBreakers[u.Host] = shared
+	}
+	return shared, true
+}
+
+// RegisterBreaker records b as the circuit breaker in front of the named service, so it can be
+// reached by name (see BreakerFor). Every default service's breaker is registered; a service built
+// with its own breaker (see HTTPFetcher) registers it to be configured the same way, and wraps its
+// fetcher with WrapBreaker to be grouped by host like the defaults.
+func RegisterBreaker(name string, b *Breaker) {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	breakers[name] = b
+}
+
+// BreakerFor returns the circuit breaker registered for the named service. Its calls go through
+// its host's shared breaker instead while breakers are grouped by host (see SetBreakerGrouping).
+func BreakerFor(name string) (*Breaker, bool) {
+	breakersMu.RLock()
+	defer breakersMu.RUnlock()
+	b, ok := breakers[name]
+	return b, ok
+}
+
+// OpenBreakers returns how many of the named services have an open circuit breaker, their own or
+// their host's (see SetBreakerGrouping). A breaker whose open duration is up counts as closed: it
+// is ready to let a probe through. Services with no registered breaker count as closed too.
+func OpenBreakers(names []string) int {
+	open := 0
+	for _, name := range names {
+		if b, ok := activeBreaker(name); ok && b.State() == StateOpen {
+			open++
+		}
+	}
+	return open
+}
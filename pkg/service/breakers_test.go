@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHostBreakerShortCircuitsEveryServiceOnTheHost(t *testing.T) {
+	for _, name := range []string{"host-a", "host-b", "host-other"} {
+		RegisterBreaker(name, newDefaultBreaker())
+	}
+	SetFetchConfig("host-a", FetchConfig{BaseURL: "http://shared.test:9090"})
+	SetFetchConfig("host-b", FetchConfig{BaseURL: "http://shared.test:9090/"})
+	SetFetchConfig("host-other", FetchConfig{BaseURL: "http://other.test:9090"})
+	t.Cleanup(func() { SetBreakerGrouping(BreakerPerService) })
+
+	calls := map[string]int{}
+	fetcher := func(name string, err error) Fetcher {
+		return WrapBreaker(name, func(ctx context.Context, userID string) (any, error) {
+			calls[name]++
+			return nil, err
+		})
+	}
+	down := errors.New("host down")
+	a, b, other := fetcher("host-a", down), fetcher("host-b", nil), fetcher("host-other", nil)
+
+	tests := []struct {
+		grouping  BreakerGrouping
+		bTripped  bool // whether host-b fails fast once host-a has tripped its breaker
+		openCount int  // OpenBreakers over all three
+	}{
+		{BreakerPerService, false, 1},
+		{BreakerPerHost, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.grouping), func(t *testing.T) {
+			SetBreakerGrouping(tt.grouping)
+			clear(calls)
+			for range 5 {
+				a(context.Background(), "1")
+			}
+			if _, err := a(context.Background(), "1"); !errors.Is(err, ErrCircuitOpen) || calls["host-a"] != 5 {
+				t.Fatalf("host-a after 5 failures: %v after %d calls, want %v", err, calls["host-a"], ErrCircuitOpen)
+			}
+
+			_, err := b(context.Background(), "1")
+			tripped, called := errors.Is(err, ErrCircuitOpen), calls["host-b"] == 1
+			if tripped != tt.bTripped || called == tt.bTripped {
+				t.Fatalf("host-b: %v after %d calls, want tripped %v", err, calls["host-b"], tt.bTripped)
+			}
+			if _, err := other(context.Background(), "1"); err != nil {
+				t.Fatalf("host-other: %v, want it unaffected", err)
+			}
+			if got := OpenBreakers([]string{"host-a", "host-b", "host-other"}); got != tt.openCount {
+				t.Fatalf("OpenBreakers() = %d, want %d", got, tt.openCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBulkheadFull is returned without calling the downstream when the service already has as many
+// calls in flight as its bulkhead allows (see SetBulkhead).
+var ErrBulkheadFull = errors.New("service bulkhead full")
+
+var (
+	bulkheadsMu sync.RWMutex
+	bulkheads   = make(map[string]chan struct{}) // service name -> one token per call in flight
+)
+
+// SetBulkhead caps how many calls to the named service may be in flight at once, so one slow
+// service can't tie up the gateway's goroutines and connections at the expense of the others.
+// Calls over the cap fail fast with ErrBulkheadFull instead of queueing. size <= 0 removes the cap.
+//
+// Unlike SetMaxConcurrency, which is one limit shared by every service, each service's
+// bulkhead is its own. As with that limit, calls in flight keep the bulkhead they entered.
+func SetBulkhead(name string, size int) {
+	bulkheadsMu.Lock()
+	defer bulkheadsMu.Unlock()
+	if size <= 0 {
+		delete(bulkheads, name)
+		return
+	}
+	bulkheads[name] = make(chan struct{}, size)
+}
+
+// enterBulkhead takes a place in name's bulkhead without waiting.
+// The returned release func must be called once the call has finished.
+func enterBulkhead(name string) (release func(), err error) {
+	bulkheadsMu.RLock()
+	tokens, ok := bulkheads[name]
+	bulkheadsMu.RUnlock()
+
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case tokens <- struct{}{}:
+		return func() { <-tokens }, nil
+	default:
+		return nil, fmt.Errorf("%w: %s has %d calls in flight", ErrBulkheadFull, name, cap(tokens))
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBulkheadIsolatesServices(t *testing.T) {
+	slow := newCountingServer(t, time.Minute)
+	fast := newCountingServer(t, 0)
+	SetFetchConfig("bulkhead-slow", FetchConfig{BaseURL: slow.URL})
+	SetFetchConfig("bulkhead-fast", FetchConfig{BaseURL: fast.URL})
+	SetBulkhead("bulkhead-slow", 2)
+	defer SetBulkhead("bulkhead-slow", 0)
+	SetBulkhead("bulkhead-fast", 2)
+	defer SetBulkhead("bulkhead-fast", 0)
+
+	// Saturate the slow service's bulkhead.
+	fetchSlow := HTTPFetcher("bulkhead-slow", "/x/")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for range 2 {
+		go fetchSlow(ctx, "123")
+	}
+	for slow.inFlight.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Its next call fails fast instead of queueing...
+	start := time.Now()
+	if _, err := fetchSlow(context.Background(), "123"); !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("call over the bulkhead returned %v, want %v", err, ErrBulkheadFull)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Fatalf("call over the bulkhead took %s, want it to fail fast", elapsed)
+	}
+
+	// ...while another service's calls still go through.
+	fetchFast := HTTPFetcher("bulkhead-fast", "/x/")
+	for range 5 {
+		if _, err := fetchFast(context.Background(), "123"); err != nil {
+			t.Fatalf("other service's call returned %v", err)
+		}
+	}
+}
+
+func TestBulkheadFreesPlacesWhenCallsFinish(t *testing.T) {
+	SetBulkhead("bulkhead-test", 1)
+	defer SetBulkhead("bulkhead-test", 0)
+
+	leave, err := enterBulkhead("bulkhead-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enterBulkhead("bulkhead-test"); !errors.Is(err, ErrBulkheadFull) {
+		t.Fatalf("second call returned %v, want %v", err, ErrBulkheadFull)
+	}
+	leave()
+	leave, err = enterBulkhead("bulkhead-test")
+	if err != nil {
+		t.Fatalf("call after the first finished returned %v", err)
+	}
+	leave()
+}
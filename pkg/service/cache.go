@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ResponseCache caches successful downstream responses for the default services.
+// Only services with a TTL are cached; see Cache.SetTTL.
+var ResponseCache = NewCache()
+
+// cacheEntry is one cached value, when it was stored, when it stops being valid, and until when
+// it may still stand in for a failed fetch (see Cache.SetStaleIfError).
+type cacheEntry struct {
+	val        any
+	stored     time.Time
+	expires    time.Time
+	staleUntil time.Time
+}
+
+// Cache is an in-memory TTL cache for downstream responses.
+//
+// Expired entries are dropped lazily when read (or, for a service serving stale data on errors,
+// once that window is over too), and a background janitor sweeps the rest every minute so keys
+// that are never read again don't pile up.
+type Cache struct {
+	mu       sync.RWMutex
+	entries  map[string]map[string]cacheEntry // key -> partition (see cachePartition) -> entry
+	ttls     map[string]time.Duration         // service name -> TTL used by Wrap
+	stale    map[string]time.Duration         // service name -> how long past its TTL Wrap may serve an entry on error
+	policies map[string]WritePolicy           // service name -> what WrapWriter does after a write
+	writes   uint64                           // writes so far, so a read that raced a write isn't cached
+	now      func() time.Time
+	stop     chan struct{}
+}
+
+// NewCache returns an empty cache and starts its janitor. Call Stop to end the janitor.
+func NewCache() *Cache {
+	c := &Cache{
+		entries:  make(map[string]map[string]cacheEntry),
+		ttls:     make(map[string]time.Duration),
+		stale:    make(map[string]time.Duration),
+		policies: make(map[string]WritePolicy),
+		now:      time.Now,
+		stop:     make(chan struct{}),
+	}
+	go c.janitor(time.Minute)
+	return c
+}
+
+// Get returns the value stored under key if it hasn't expired.
+func (c *Cache) Get(key string) (any, bool) {
+	return c.get(key, "", 0)
+}
+
+// get returns the value stored under key in partition if it hasn't expired and, when maxAge > 0,
+// was stored less than maxAge ago. An entry too old for maxAge is left in place for others.
+func (c *Cache) get(key, partition string, maxAge time.Duration) (any, bool) {
+	c.mu.RLock()
+	e, ok := c.entries[key][partition]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	if !c.now().Before(e.expires) {
+		c.mu.Lock()
+		// Re-check under the write lock: a concurrent Set may have refreshed the entry.
+		if cur, ok := c.entries[key][partition]; ok && !c.now().Before(cur.staleUntil) {
+			c.deleteLocked(key, partition)
+		}
+		c.mu.Unlock()
+		return nil, false
+	}
+	if maxAge > 0 && c.now().Sub(e.stored) >= maxAge {
+		return nil, false
+	}
+	return e.val, true
+}
+
+// getStale returns the value stored under key in partition if it has expired but is still inside
+// its stale-if-error window.
+func (c *Cache) getStale(key, partition string) (any, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key][partition]
+	if !ok || !c.now().Before(e.staleUntil) {
+		return nil, false
+	}
+	return e.val, true
+}
+
+// Set stores val under key for ttl.
+func (c *Cache) Set(key string, val any, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(key, "", c.entry(val, ttl, 0))
+}
+
+// setLocked stores e under key in partition. Caller must hold c.mu.
+func (c *Cache) setLocked(key, partition string, e cacheEntry) {
+	if c.entries[key] == nil {
+		c.entries[key] = make(map[string]cacheEntry)
+	}
+	c.entries[key][partition] = e
+}
+
+// deleteLocked removes the entry stored under key in partition. Caller must hold c.mu.
+func (c *Cache) deleteLocked(key, partition string) {
+	delete(c.entries[key], partition)
+	if len(c.entries[key]) == 0 {
+		delete(c.entries, key)
+	}
+}
+
+// entry returns val as an entry stored now for ttl, kept stale for up to stale more.
+func (c *Cache) entry(val any, ttl, stale time.Duration) cacheEntry {
+	now := c.now()
+	return cacheEntry{val: val, stored: now, expires: now.Add(ttl), staleUntil: now.Add(ttl + stale)}
+}
+
+// Delete removes the value stored under key.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteLocked(key, "")
+}
+
+// SetTTL sets how long Wrap caches the named service's responses. Zero disables caching for it.
+func (c *Cache) SetTTL(name string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttls[name] = ttl
+}
+
+// ttl returns the TTL configured for name.
+func (c *Cache) ttl(name string) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ttls[name]
+}
+
+// SetStaleIfError sets how long past its TTL Wrap may still serve one of the named service's
+// responses when fetching a fresh one fails, e.g. because its circuit breaker is open.
+// Zero, the default, never serves expired data.
+func (c *Cache) SetStaleIfError(name string, window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale[name] = window
+}
+
+// SetWritePolicy sets what WrapWriter does to name's cached responses after a successful write.
+// The default is WriteInvalidate.
+func (c *Cache) SetWritePolicy(name string, policy WritePolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policies[name] = policy
+}
+
+// writePolicy returns the write policy configured for name.
+func (c *Cache) writePolicy(name string) WritePolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if policy, ok := c.policies[name]; ok {
+		return policy
+	}
+	return WriteInvalidate
+}
+
+// Wrap returns a Fetcher that serves name's responses from the cache, keyed by name + ":" + userID
+// and partitioned by the caller (see WithSubject and WithForwardedHeaders), and only calls fetcher on a miss: a response
+// fetched for one caller is never served to another, whatever user_id they asked for. A caller can ask for fresher data than the TTL guarantees with
+// WithMaxAge: an entry older than that is a miss, and the refreshed response replaces it.
+// Errors and maintenance results are never cached, and neither is a response fetched while any
+// write went through (see WrapWriter): it may predate the write. When the fetch fails, an expired
+// entry still inside the service's stale-if-error window (see SetStaleIfError) is served instead.
+// Responses served from the cache are recorded in ctx's FreshnessLog, if it has one. A context
+// from WithCacheBypass or WithUpstream skips the cache both ways.
+func (c *Cache) Wrap(name string, fetcher Fetcher) Fetcher {
+	return func(ctx context.Context, userID string) (any, error) {
+		ttl := c.ttl(name)
+		if ttl <= 0 || CacheBypassed(ctx) || Upstream(ctx) != nil {
+			return fetcher(ctx, userID)
+		}
+
+		key, partition := name+":"+userID, cachePartition(ctx)
+		lookup := time.Now()
+		val, ok := c.get(key, partition, MaxAge(ctx))
+		recordLookup(ctx, time.Since(lookup))
+		if ok {
+			recordFreshness(ctx, name, FreshCache)
+			return val, nil
+		}
+
+		c.mu.RLock()
+		writes := c.writes
+		stale := c.stale[name]
+		c.mu.RUnlock()
+
+		data, err := fetcher(ctx, userID)
+		if err != nil {
+			if val, ok := c.getStale(key, partition); ok {
+				recordFreshness(ctx, name, FreshStale)
+				return val, nil
+			}
+			return data, err
+		}
+		if !IsPaused(name) {
+			c.mu.Lock()
+			if c.writes == writes {
+				c.setLocked(key, partition, c.entry(data, ttl, stale))
+			}
+			c.mu.Unlock()
+		}
+		return data, nil
+	}
+}
+
+// WrapWriter returns a Writer that calls writer and then, before returning, updates name's
+// cached response for the user according to its write policy (see SetWritePolicy), so a read
+// that follows the write never sees the data from before it. Every caller's entry for the user is
+// dropped; with WriteThrough the writer's own is then replaced by the write's response. A failed
+// write always invalidates: the downstream may or may not have applied it.
+func (c *Cache) WrapWriter(name string, writer Writer) Writer {
+	return func(ctx context.Context, userID string, body any) (any, error) {
+		data, err := writer(ctx, userID, body)
+
+		key := name + ":" + userID
+		ttl := c.ttl(name)
+		policy := c.writePolicy(name)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.writes++
+		delete(c.entries, key)
+		if err == nil && policy == WriteThrough && ttl > 0 {
+			c.setLocked(key, cachePartition(ctx), c.entry(data, ttl, c.stale[name]))
+		}
+		return data, err
+	}
+}
+
+// Stop ends the janitor goroutine.
+func (c *Cache) Stop() {
+	close(c.stop)
+}
+
+// janitor removes expired entries every interval until Stop is called.
+func (c *Cache) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.sweep()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// sweep deletes every expired entry.
+func (c *Cache) sweep() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for key, partitions := range c.entries {
+		for partition, e := range partitions {
+			if !now.Before(e.staleUntil) {
+				c.deleteLocked(key, partition)
+			}
+		}
+	}
+}
+
+type maxAgeKey struct{}
+
+// WithMaxAge returns a copy of ctx asking cached fetchers (see Cache.Wrap) for data stored less
+// than maxAge ago, refreshing anything older, e.g. for a client that sent ?max_age=5.
+func WithMaxAge(ctx context.Context, maxAge time.Duration) context.Context {
+	return context.WithValue(ctx, maxAgeKey{}, maxAge)
+}
+
+// MaxAge returns the max age stored in ctx by WithMaxAge, or 0 for none.
+func MaxAge(ctx context.Context) time.Duration {
+	maxAge, _ := ctx.Value(maxAgeKey{}).(time.Duration)
+	return maxAge
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass returns a copy of ctx whose fetches neither read from nor store into cached
+// fetchers (see Cache.Wrap), e.g. for a request debugging what a service answers right now.
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// CacheBypassed reports whether ctx came from WithCacheBypass.
+func CacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+type subjectKey struct{}
+
+// WithSubject returns a copy of ctx whose fetches are made on behalf of subject, the caller's
+// authenticated identity, e.g. a token's sub claim. Cached fetchers (see Cache.Wrap) keep each
+// subject's responses apart.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// Subject returns the subject stored in ctx by WithSubject, or "" for none.
+func Subject(ctx context.Context) string {
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	return subject
+}
+
+// cachePartition returns the part of a cache key that identifies the caller ctx belongs to: its
+// subject and the credentials forwarded downstream on its behalf, the same ones Coalesce keys on,
+// since the downstream may answer differently for each.
+func cachePartition(ctx context.Context) string {
+	return Subject(ctx) + "\x00" + credentials(ctx)
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestCache returns a cache whose clock reads *now.
+func newTestCache(t *testing.T, now *time.Time) *Cache {
+	c := NewCache()
+	t.Cleanup(c.Stop)
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestCacheWrapServesRepeatCallsFromCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCache(t, &now)
+	c.SetTTL("user", time.Minute)
+
+	calls := 0
+	fetch := c.Wrap("user", func(ctx context.Context, userID string) (any, error) {
+		calls++
+		return userID, nil
+	})
+
+	for range 2 {
+		if data, err := fetch(context.Background(), "123"); err != nil || data != "123" {
+			t.Fatalf("fetch() = %v, %v", data, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("fetcher called %d times within the TTL, want 1", calls)
+	}
+
+	// Other users have their own entries.
+	fetch(context.Background(), "456")
+	if calls != 2 {
+		t.Fatalf("fetcher called %d times, want 2", calls)
+	}
+
+	// Past the TTL the entry is evicted on read and fetched again.
+	now = now.Add(time.Minute)
+	fetch(context.Background(), "123")
+	if calls != 3 {
+		t.Fatalf("fetcher called %d times after the TTL, want 3", calls)
+	}
+
+	// A caller asking for fresher data than the entry refreshes it.
+	now = now.Add(10 * time.Second)
+	fetch(WithMaxAge(context.Background(), 5*time.Second), "123")
+	if calls != 4 {
+		t.Fatalf("fetcher called %d times with a max age, want 4", calls)
+	}
+}
+
+func TestCacheWrapWithoutTTLAlwaysFetches(t *testing.T) {
+	now := time.Now()
+	c := newTestCache(t, &now)
+
+	calls := 0
+	fetch := c.Wrap("orders", func(ctx context.Context, userID string) (any, error) {
+		calls++
+		return userID, nil
+	})
+	fetch(context.Background(), "123")
+	fetch(context.Background(), "123")
+	if calls != 2 {
+		t.Fatalf("fetcher called %d times without a TTL, want 2", calls)
+	}
+}
+
+func TestCacheSweepDropsExpiredEntries(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCache(t, &now)
+	c.Set("short", 1, time.Second)
+	c.Set("long", 2, time.Hour)
+
+	now = now.Add(time.Minute)
+	c.sweep()
+	if _, ok := c.entries["short"]; ok {
+		t.Fatal("expired entry survived the sweep")
+	}
+	if v, ok := c.Get("long"); !ok || v != 2 {
+		t.Fatalf("Get(long) = %v, %v, want the live entry", v, ok)
+	}
+}
+
+func TestCacheConcurrentUse(t *testing.T) {
+	c := NewCache()
+	defer c.Stop()
+	c.SetTTL("user", time.Minute)
+	fetch := c.Wrap("user", func(ctx context.Context, userID string) (any, error) {
+		return userID, nil
+	})
+	write := c.WrapWriter("user", func(ctx context.Context, userID string, body any) (any, error) {
+		return body, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := []string{"1", "2", "3"}[i%3]
+			if i%5 == 0 {
+				write(context.Background(), id, id)
+				return
+			}
+			if data, err := fetch(context.Background(), id); err != nil || data != id {
+				t.Errorf("fetch(%s) = %v, %v", id, data, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestCacheWrapServesStaleDataWhenTheFetchFails(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCache(t, &now)
+	c.SetTTL("user", time.Minute)
+	c.SetStaleIfError("user", time.Minute)
+
+	failing := false
+	fetch := c.Wrap("user", func(ctx context.Context, userID string) (any, error) {
+		if failing {
+			return nil, ErrCircuitOpen
+		}
+		return "fresh-" + userID, nil
+	})
+	freshness := func(ctx context.Context, log *FreshnessLog) (any, Freshness) {
+		data, err := fetch(ctx, "123")
+		if err != nil {
+			t.Fatalf("fetch() = %v", err)
+		}
+		return data, log.Of("user")
+	}
+
+	ctx, log := WithFreshnessLog(context.Background())
+	if data, f := freshness(ctx, log); data != "fresh-123" || f != FreshLive {
+		t.Fatalf("first fetch = %v (%s), want fresh-123 (%s)", data, f, FreshLive)
+	}
+	ctx, log = WithFreshnessLog(context.Background())
+	if data, f := freshness(ctx, log); data != "fresh-123" || f != FreshCache {
+		t.Fatalf("fetch within the TTL = %v (%s), want fresh-123 (%s)", data, f, FreshCache)
+	}
+
+	// Past the TTL a failed fetch is answered with the expired entry...
+	now = now.Add(90 * time.Second)
+	failing = true
+	ctx, log = WithFreshnessLog(context.Background())
+	if data, f := freshness(ctx, log); data != "fresh-123" || f != FreshStale {
+		t.Fatalf("failed fetch inside the stale window = %v (%s), want fresh-123 (%s)", data, f, FreshStale)
+	}
+
+	// ...but not past the stale window too.
+	now = now.Add(time.Minute)
+	if _, err := fetch(context.Background(), "123"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("failed fetch past the stale window = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestCacheWrapWithoutStaleWindowReturnsTheError(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCache(t, &now)
+	c.SetTTL("user", time.Minute)
+
+	failing := false
+	fetch := c.Wrap("user", func(ctx context.Context, userID string) (any, error) {
+		if failing {
+			return nil, ErrCircuitOpen
+		}
+		return userID, nil
+	})
+	fetch(context.Background(), "123")
+	now = now.Add(time.Minute)
+	failing = true
+	if _, err := fetch(context.Background(), "123"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("failed fetch past the TTL = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestCacheWrapBypassedContextSkipsTheCache(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCache(t, &now)
+	c.SetTTL("user", time.Minute)
+
+	calls := 0
+	fetch := c.Wrap("user", func(ctx context.Context, userID string) (any, error) {
+		calls++
+		return calls, nil
+	})
+	fetch(context.Background(), "123")
+
+	// Bypassing reads live and doesn't replace the cached entry.
+	bypass := WithCacheBypass(context.Background())
+	if data, _ := fetch(bypass, "123"); data != 2 {
+		t.Fatalf("bypassed fetch = %v, want a live 2", data)
+	}
+	if data, _ := fetch(context.Background(), "123"); data != 1 {
+		t.Fatalf("fetch after a bypass = %v, want the cached 1", data)
+	}
+}
+
+func TestCacheWrapKeepsSubjectsApart(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCache(t, &now)
+	c.SetTTL("user", time.Minute)
+
+	calls := 0
+	fetch := c.Wrap("user", func(ctx context.Context, userID string) (any, error) {
+		calls++
+		return Subject(ctx) + " saw " + userID, nil
+	})
+	write := c.WrapWriter("user", func(ctx context.Context, userID string, body any) (any, error) {
+		return body, nil
+	})
+	alice := WithSubject(context.Background(), "alice")
+	admin := WithSubject(context.Background(), "admin")
+
+	fetch(alice, "123")
+	if data, _ := fetch(admin, "123"); data != "admin saw 123" || calls != 2 {
+		t.Fatalf("admin got %v after %d calls, want its own response", data, calls)
+	}
+	if data, _ := fetch(alice, "123"); data != "alice saw 123" || calls != 2 {
+		t.Fatalf("alice got %v after %d calls, want the cached one", data, calls)
+	}
+
+	// A write by either drops both entries.
+	write(admin, "123", "new")
+	fetch(alice, "123")
+	fetch(admin, "123")
+	if calls != 4 {
+		t.Fatalf("fetcher called %d times after a write, want 4", calls)
+	}
+}
+
+func TestCacheWrapKeepsCredentialsApart(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCache(t, &now)
+	c.SetTTL("user", time.Minute)
+	SetForwardHeaders([]string{"Authorization"})
+	defer SetForwardHeaders(nil)
+
+	calls := 0
+	fetch := c.Wrap("user", func(ctx context.Context, userID string) (any, error) {
+		calls++
+		return forwardedHeaders(ctx).Get("Authorization") + " saw " + userID, nil
+	})
+	withToken := func(token string) context.Context {
+		return WithForwardedHeaders(context.Background(), http.Header{"Authorization": {"Bearer " + token}})
+	}
+	a, b := withToken("a"), withToken("b")
+
+	fetch(a, "123")
+	if data, _ := fetch(b, "123"); data != "Bearer b saw 123" || calls != 2 {
+		t.Fatalf("credentials b got %v after %d calls, want their own response", data, calls)
+	}
+	if data, _ := fetch(withToken("a"), "123"); data != "Bearer a saw 123" || calls != 2 {
+		t.Fatalf("credentials a got %v after %d calls, want the cached one", data, calls)
+	}
+}
+
+func TestCacheWrapHonorsMaxAge(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestCache(t, &now)
+	c.SetTTL("user", time.Minute)
+
+	calls := 0
+	fetch := c.Wrap("user", func(ctx context.Context, userID string) (any, error) {
+		calls++
+		return calls, nil
+	})
+	within5s := WithMaxAge(context.Background(), 5*time.Second)
+
+	fetch(context.Background(), "123")
+
+	// Younger than max_age: served from the cache.
+	now = now.Add(3 * time.Second)
+	if data, _ := fetch(within5s, "123"); data != 1 || calls != 1 {
+		t.Fatalf("3s old with max_age=5s: got %v after %d calls, want the cached 1", data, calls)
+	}
+
+	// Older than max_age, though well within the TTL: refreshed, and the refresh is cached.
+	now = now.Add(3 * time.Second)
+	if data, _ := fetch(within5s, "123"); data != 2 || calls != 2 {
+		t.Fatalf("6s old with max_age=5s: got %v after %d calls, want a refreshed 2", data, calls)
+	}
+	if data, _ := fetch(context.Background(), "123"); data != 2 || calls != 2 {
+		t.Fatalf("without max_age after the refresh: got %v after %d calls, want the cached 2", data, calls)
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Coalesce returns a Fetcher that shares one in-flight call to fetcher between concurrent
+// callers asking for the same userID with the same forwarded credentials (see credentials) and
+// upstream (see WithUpstream).
+// Each wrapper has its own set of calls, so wrapping one service's fetcher keys calls by
+// name + userID in effect; callers forwarding different credentials never share a call.
+//
+// The shared call runs under its own context (see callContext): it carries the first caller's
+// values, it is cancelled once every caller waiting on it has given up, and its deadline is the
+// latest of its callers', so one caller giving up or timing out doesn't fail the others.
+// Each caller still returns as soon as its own ctx is done.
+// Callers get the same value and error, so the result must not be mutated.
+// A panic in fetcher is turned into an error for every caller instead of re-panicking in each.
+func Coalesce(fetcher Fetcher) Fetcher {
+	var (
+		mu    sync.Mutex
+		calls = make(map[string]*call)
+	)
+	return func(ctx context.Context, userID string) (any, error) {
+		key := userID + "\x00" + credentials(ctx)
+		if base := Upstream(ctx); base != nil {
+			key += "\x00" + base.String()
+		}
+
+		mu.Lock()
+		cl, ok := calls[key]
+		if ok {
+			cl.ctx.extend(ctx)
+		} else {
+			cl = &call{ctx: newCallContext(ctx), finished: make(chan struct{})}
+			calls[key] = cl
+			go func() {
+				data, err := cl.run(fetcher, userID)
+				mu.Lock()
+				if calls[key] == cl {
+					delete(calls, key)
+				}
+				mu.Unlock()
+				cl.data, cl.err = data, err
+				close(cl.finished)
+				cl.ctx.cancel(context.Canceled)
+			}()
+		}
+		cl.waiters++
+		mu.Unlock()
+
+		select {
+		case <-cl.finished:
+			return cl.data, cl.err
+		case <-ctx.Done():
+			mu.Lock()
+			cl.waiters--
+			if cl.waiters == 0 {
+				// Nobody wants the result any more: drop the call so the next caller starts afresh.
+				if calls[key] == cl {
+					delete(calls, key)
+				}
+				cl.ctx.cancel(context.Canceled)
+			}
+			mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// call is one in-flight fetch shared by Coalesce's callers.
+type call struct {
+	ctx      *callContext
+	waiters  int           // guarded by Coalesce's mutex
+	finished chan struct{} // closed once data and err are set
+	data     any
+	err      error
+}
+
+func (cl *call) run(fetcher Fetcher, userID string) (data any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("fetch panicked: %v", r)
+		}
+	}()
+	return fetcher(cl.ctx, userID)
+}
+
+// callContext is the context a coalesced call runs under. Values come from the caller that
+// started the call. It is done when cancel is called (every caller left, or the call finished)
+// or when its deadline passes, which extend moves to the latest deadline of the callers that
+// joined; a caller without a deadline lifts it altogether.
+type callContext struct {
+	values context.Context
+	done   chan struct{}
+
+	mu       sync.Mutex
+	deadline time.Time // zero when some caller has no deadline
+	timer    *time.Timer
+	err      error
+}
+
+func newCallContext(parent context.Context) *callContext {
+	c := &callContext{values: parent, done: make(chan struct{})}
+	if deadline, ok := parent.Deadline(); ok {
+		c.deadline = deadline
+		c.timer = time.AfterFunc(time.Until(deadline), c.expire)
+	}
+	return c
+}
+
+// extend moves the deadline to joining's if that is later, or lifts it if joining has none.
+func (c *callContext) extend(joining context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil || c.deadline.IsZero() {
+		return
+	}
+	deadline, ok := joining.Deadline()
+	switch {
+	case !ok:
+		c.deadline = time.Time{}
+		c.timer.Stop()
+	case deadline.After(c.deadline):
+		c.deadline = deadline
+		c.timer.Reset(time.Until(deadline))
+	}
+}
+
+// expire ends the context with context.DeadlineExceeded, unless extend moved the deadline
+// since the timer was set.
+func (c *callContext) expire() {
+	c.mu.Lock()
+	if c.deadline.IsZero() || time.Now().Before(c.deadline) {
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+	c.cancel(context.DeadlineExceeded)
+}
+
+func (c *callContext) cancel(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	close(c.done)
+}
+
+func (c *callContext) Deadline() (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.deadline, !c.deadline.IsZero()
+}
+
+func (c *callContext) Done() <-chan struct{} { return c.done }
+
+func (c *callContext) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *callContext) Value(key any) any { return c.values.Value(key) }
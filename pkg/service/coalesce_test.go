@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalesceSharesOneCall(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := Coalesce(func(ctx context.Context, userID string) (any, error) {
+		calls.Add(1)
+		<-release
+		return userID, nil
+	})
+
+	var wg sync.WaitGroup
+	results := make([]any, 50)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = fetch(context.Background(), "123")
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("fetcher called %d times, want 1", n)
+	}
+	for i, r := range results {
+		if r != "123" {
+			t.Fatalf("caller %d got %v, want 123", i, r)
+		}
+	}
+}
+
+func TestCoalesceKeysOnCredentials(t *testing.T) {
+	SetForwardHeaders([]string{"Authorization"})
+	defer SetForwardHeaders(nil)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := Coalesce(func(ctx context.Context, userID string) (any, error) {
+		calls.Add(1)
+		<-release
+		return forwardedHeaders(ctx).Get("Authorization"), nil
+	})
+
+	var wg sync.WaitGroup
+	got := make([]any, 2)
+	for i, token := range []string{"Bearer a", "Bearer b"} {
+		ctx := WithForwardedHeaders(context.Background(), http.Header{"Authorization": {token}})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got[i], _ = fetch(ctx, "123")
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 2 {
+		t.Fatalf("fetcher called %d times, want 2", n)
+	}
+	if got[0] != "Bearer a" || got[1] != "Bearer b" {
+		t.Fatalf("got %v, want each caller's own credentials", got)
+	}
+}
+
+func TestCoalesceCancelsWhenEveryCallerLeaves(t *testing.T) {
+	cancelled := make(chan error, 1)
+	fetch := Coalesce(func(ctx context.Context, userID string) (any, error) {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	})
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() { _, err := fetch(ctx1, "123"); errs <- err }()
+	go func() { _, err := fetch(ctx2, "123"); errs <- err }()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel1()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller got %v, want context.Canceled", err)
+	}
+	select {
+	case <-cancelled:
+		t.Fatal("shared call cancelled while a caller was still waiting")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel2()
+	<-errs
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("shared call ended with %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("shared call not cancelled after the last caller left")
+	}
+}
+
+func TestCoalesceCarriesLatestDeadline(t *testing.T) {
+	deadlines := make(chan time.Time, 1)
+	fetch := Coalesce(func(ctx context.Context, userID string) (any, error) {
+		<-ctx.Done()
+		deadline, _ := ctx.Deadline()
+		deadlines <- deadline
+		return nil, ctx.Err()
+	})
+
+	short, cancel1 := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel1()
+	long, cancel2 := context.WithTimeout(context.Background(), 80*time.Millisecond)
+	defer cancel2()
+
+	errs := make(chan error, 2)
+	go func() { _, err := fetch(short, "123"); errs <- err }()
+	time.Sleep(5 * time.Millisecond)
+	go func() { _, err := fetch(long, "123"); errs <- err }()
+
+	want, _ := long.Deadline()
+	select {
+	case got := <-deadlines:
+		if !got.Equal(want) {
+			t.Fatalf("shared call ended at deadline %v, want the later %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("shared call never hit its deadline")
+	}
+	for range 2 {
+		if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("caller got %v, want context.DeadlineExceeded", err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+// CorrelationFormat is how the request ID is written into a downstream's correlation header.
+type CorrelationFormat string
+
+const (
+	// CorrelationRaw sends the request ID as it is, e.g. X-Correlation-ID: 4bf92f35...
+	CorrelationRaw CorrelationFormat = "raw"
+
+	// CorrelationTraceparent sends a W3C traceparent whose trace ID is the request ID (or, if that
+	// isn't 32 hex digits, a hash of it), e.g. traceparent: 00-4bf92f35...-00f067aa0ba902b7-01.
+	CorrelationTraceparent CorrelationFormat = "traceparent"
+)
+
+// Correlation is the header a downstream expects the request ID in, and its format.
+type Correlation struct {
+	Header string
+	Format CorrelationFormat
+}
+
+// DefaultCorrelation is what services without their own Correlation get.
+var DefaultCorrelation = Correlation{Header: "X-Request-ID", Format: CorrelationRaw}
+
+var (
+	correlationMu sync.RWMutex
+	correlations  = make(map[string]Correlation)
+)
+
+// SetCorrelation sets the header, and its format, the named service gets the request ID in,
+// so its logs can be joined with the gateway's. E.g. for a service expecting W3C trace context:
+//
+//	SetCorrelation("orders", Correlation{Header: "traceparent", Format: CorrelationTraceparent})
+//
+// When tracing is on, a traceparent for the live span replaces the derived one (the span's trace
+// is what the service should join). A zero Correlation restores DefaultCorrelation.
+func SetCorrelation(name string, c Correlation) {
+	correlationMu.Lock()
+	defer correlationMu.Unlock()
+	if c == (Correlation{}) {
+		delete(correlations, name)
+		return
+	}
+	correlations[name] = c
+}
+
+// correlationFor returns the Correlation configured for name.
+func correlationFor(name string) Correlation {
+	correlationMu.RLock()
+	defer correlationMu.RUnlock()
+	if c, ok := correlations[name]; ok {
+		return c
+	}
+	return DefaultCorrelation
+}
+
+// correlate sets name's correlation header for request ID id on h. When the header value isn't the
+// ID itself, the mapping is logged so the downstream's entries can be traced back to the request.
+func correlate(h http.Header, name, id string) {
+	c := correlationFor(name)
+	value := id
+	if c.Format == CorrelationTraceparent {
+		value = traceparent(name, id)
+		slog.Info("correlation", "request_id", id, "service", name, "header", c.Header, "value", value)
+	}
+	h.Set(c.Header, value)
+}
+
+// traceparent derives a sampled W3C traceparent from request ID id. The parent ID is a hash of the
+// ID and the service, so every call to a service for one request carries the same traceparent.
+func traceparent(name, id string) string {
+	traceID := id
+	if b, err := hex.DecodeString(id); err != nil || len(b) != 16 || id != hex.EncodeToString(b) {
+		sum := sha256.Sum256([]byte(id))
+		traceID = hex.EncodeToString(sum[:16])
+	}
+	span := sha256.Sum256([]byte(id + "/" + name))
+	return "00-" + traceID + "-" + hex.EncodeToString(span[:8]) + "-01"
+}
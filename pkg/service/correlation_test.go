@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestEachServiceGetsTheRequestIDInItsCorrelationHeader(t *testing.T) {
+	var mu sync.Mutex
+	headers := make(map[string]http.Header)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		headers[strings.TrimPrefix(r.URL.Path, "/")] = r.Header.Clone()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	correlations := map[string]Correlation{
+		"corr-default": {},
+		"corr-raw":     {Header: "X-Correlation-ID", Format: CorrelationRaw},
+		"corr-trace":   {Header: "traceparent", Format: CorrelationTraceparent},
+	}
+	for name, c := range correlations {
+		SetCorrelation(name, c)
+		defer SetCorrelation(name, Correlation{})
+		SetFetchConfig(name, FetchConfig{BaseURL: srv.URL})
+		defer SetFetchConfig(name, FetchConfig{})
+	}
+
+	const id = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := WithRequestID(context.Background(), id)
+	for name := range correlations {
+		if _, err := HTTPFetcher(name, "/")(ctx, name); err != nil {
+			t.Fatalf("fetch %s: %v", name, err)
+		}
+	}
+
+	if got := headers["corr-default"].Get("X-Request-ID"); got != id {
+		t.Errorf("corr-default X-Request-ID = %q, want %q", got, id)
+	}
+	if got := headers["corr-raw"].Get("X-Correlation-ID"); got != id {
+		t.Errorf("corr-raw X-Correlation-ID = %q, want %q", got, id)
+	}
+	if got := headers["corr-raw"].Get("X-Request-ID"); got != "" {
+		t.Errorf("corr-raw also got X-Request-ID %q, want only its own header", got)
+	}
+	traceparentRE := regexp.MustCompile(`^00-` + id + `-[0-9a-f]{16}-01$`)
+	if got := headers["corr-trace"].Get("traceparent"); !traceparentRE.MatchString(got) {
+		t.Errorf("corr-trace traceparent = %q, want 00-%s-<span>-01", got, id)
+	}
+}
+
+func TestTraceparentHashesIDsThatArentATraceID(t *testing.T) {
+	got := traceparent("orders", "req-42")
+	if !regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-01$`).MatchString(got) {
+		t.Fatalf("traceparent = %q, want a valid W3C traceparent", got)
+	}
+	if again := traceparent("orders", "req-42"); again != got {
+		t.Fatalf("traceparent changed between calls: %q then %q", got, again)
+	}
+	if other := traceparent("user", "req-42"); other[:35] != got[:35] || other == got {
+		t.Fatalf("traceparent for another service = %q, want the same trace as %q with its own parent", other, got)
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Doer makes one HTTP call to a downstream service and returns its decoded JSON body.
+// body is sent as JSON when it isn't nil. Errors should use the package's sentinels
+// (ErrBadStatus, ErrParse) so Category can label them.
+//
+// Everything around the call - pausing, offline fakes, budgets, outbound slots, Stats - stays
+// in the fetchers, so a stub Doer exercises the same code paths as the real client.
+type Doer interface {
+	Do(ctx context.Context, method, url string, body any) (any, error)
+}
+
+// DoerFunc adapts an ordinary function to a Doer, e.g. a stub returning canned data:
+//
+//	service.SetClient(service.DoerFunc(func(ctx context.Context, method, url string, body any) (any, error) {
+//		return map[string]any{"name": "Jane"}, nil
+//	}))
+type DoerFunc func(ctx context.Context, method, url string, body any) (any, error)
+
+// Do calls f.
+func (f DoerFunc) Do(ctx context.Context, method, url string, body any) (any, error) {
+	return f(ctx, method, url, body)
+}
+
+var (
+	doerMu sync.RWMutex
+	doer   Doer // nil means the built-in resty clients
+)
+
+// SetClient makes every downstream call go through d instead of the built-in resty clients,
+// e.g. to test handlers against deterministic fakes without a server. nil restores the
+// built-in clients.
+func SetClient(d Doer) {
+	doerMu.Lock()
+	defer doerMu.Unlock()
+	doer = d
+}
+
+// doerFor returns the Doer for a call to name: the one set with SetClient, or the resty
+// client for cfg's per-attempt timeout (see FetchConfig.attemptClient).
+func doerFor(name string, cfg FetchConfig) Doer {
+	doerMu.RLock()
+	defer doerMu.RUnlock()
+	if doer != nil {
+		return doer
+	}
+	return restyDoer{client: cfg.attemptClient(), name: name}
+}
+
+// restyDoer is the built-in Doer. It forwards the caller's headers (see WithForwardedHeaders),
+// request id (in the service's correlation header, see SetCorrelation), hop count (see HopsHeader)
+// and trace context, and records the retries it needed in the caller's RetryLog.
+type restyDoer struct {
+	client *resty.Client
+	name   string
+}
+
+func (d restyDoer) Do(ctx context.Context, method, url string, body any) (any, error) {
+	// The service name lets the retry condition weigh the time left against its latency.
+	req := d.client.R().SetContext(withCallService(ctx, d.name))
+	for header, values := range forwardedHeaders(ctx) {
+		req.Header[header] = values
+	}
+	if id := RequestID(ctx); id != "" {
+		correlate(req.Header, d.name, id)
+	}
+	req.SetHeader(HopsHeader, strconv.Itoa(Hops(ctx)+1))
+	// traceparent for the caller's span, so downstream spans join the gateway's trace.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if body != nil {
+		req.SetBody(body)
+	}
+	data, err := decode(req.Execute(method, url))
+	if req.Attempt > 1 {
+		recordRetries(ctx, d.name, req.Attempt-1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestStubClientAnswersFetchesAndWrites(t *testing.T) {
+	type call struct{ method, url string }
+	var calls []call
+	SetClient(DoerFunc(func(ctx context.Context, method, url string, body any) (any, error) {
+		calls = append(calls, call{method, url})
+		if url == DefaultBaseURL+"/stub/broken" {
+			return nil, ErrBadStatus
+		}
+		if body != nil {
+			return body, nil
+		}
+		return map[string]any{"name": "Jane"}, nil
+	}))
+	defer SetClient(nil)
+
+	data, err := HTTPFetcher("stub", "/stub/")(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if got := data.(map[string]any)["name"]; got != "Jane" {
+		t.Fatalf("fetched name = %v, want the stub's Jane", got)
+	}
+
+	written, err := HTTPWriter("stub", "/stub/")(context.Background(), "u1", map[string]any{"name": "Joan"})
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if got := written.(map[string]any)["name"]; got != "Joan" {
+		t.Fatalf("written name = %v, want Joan", got)
+	}
+
+	if _, err := HTTPFetcher("stub", "/stub/")(context.Background(), "broken"); !errors.Is(err, ErrBadStatus) {
+		t.Fatalf("fetch of broken = %v, want %v", err, ErrBadStatus)
+	}
+
+	want := []call{
+		{http.MethodGet, DefaultBaseURL + "/stub/u1"},
+		{http.MethodPost, DefaultBaseURL + "/stub/u1"},
+		{http.MethodGet, DefaultBaseURL + "/stub/broken"},
+	}
+	if len(calls) != len(want) {
+		t.Fatalf("stub got %v, want %v", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("call %d = %v, want %v", i, calls[i], want[i])
+		}
+	}
+}
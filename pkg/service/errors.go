@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrParse marks a downstream response whose body isn't the JSON object we expect.
+	ErrParse = errors.New("invalid downstream response")
+
+	// ErrBadStatus marks a downstream response with a 4xx/5xx status.
+	ErrBadStatus = errors.New("downstream error status")
+)
+
+// Category returns a short, stable label for a fetch error so failures can be grouped
+// (e.g. "dns" vs "timeout") without parsing error strings. It returns "" for a nil error.
+func Category(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, ErrDNS):
+		return "dns"
+	case errors.Is(err, ErrPinMismatch):
+		return "tls_pin"
+	case errors.Is(err, ErrParse):
+		return "parse"
+	case errors.Is(err, ErrBadStatus):
+		return "status"
+	case errors.Is(err, ErrConcurrencyTimeout):
+		return "concurrency"
+	case errors.Is(err, ErrBulkheadFull):
+		return "bulkhead"
+	case errors.Is(err, ErrAttemptTimeout):
+		return "attempt_timeout"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	default:
+		return "upstream"
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// TimeoutEscalation is an adaptive timeout penalty for one service: every time a call
+// times out the service's timeout is cut by Factor (down to Min) so a struggling
+// service fails faster, and every RecoverAfter consecutive successes it is raised one
+// step again until it is back at Base.
+type TimeoutEscalation struct {
+	mu           sync.Mutex
+	base         time.Duration
+	min          time.Duration
+	factor       float64
+	recoverAfter int
+	current      time.Duration
+	successes    int
+}
+
+var (
+	escalationMu sync.RWMutex
+	escalations  = make(map[string]*TimeoutEscalation)
+)
+
+// SetTimeoutEscalation puts the named service's calls under e; nil removes it. For the default
+// services it applies between the circuit breaker and the HTTP call, so cached and coalesced
+// responses don't count towards it while the timeouts it cuts still trip the breaker.
+func SetTimeoutEscalation(name string, e *TimeoutEscalation) {
+	escalationMu.Lock()
+	defer escalationMu.Unlock()
+	if e == nil {
+		delete(escalations, name)
+		return
+	}
+	escalations[name] = e
+}
+
+// escalated returns a Fetcher calling fetcher under the escalation set for name, if any, at call time.
+func escalated(name string, fetcher Fetcher) Fetcher {
+	return func(ctx context.Context, userID string) (any, error) {
+		escalationMu.RLock()
+		e := escalations[name]
+		escalationMu.RUnlock()
+		if e == nil {
+			return fetcher(ctx, userID)
+		}
+		return e.Wrap(fetcher)(ctx, userID)
+	}
+}
+
+// NewTimeoutEscalation returns a policy starting at base. factor (0 < factor < 1) is
+// applied on each timeout, never going below min; recoverAfter successes undo one step.
+func NewTimeoutEscalation(base, min time.Duration, factor float64, recoverAfter int) *TimeoutEscalation {
+	if factor <= 0 || factor >= 1 {
+		factor = 0.5
+	}
+	if recoverAfter < 1 {
+		recoverAfter = 1
+	}
+	return &TimeoutEscalation{
+		base:         base,
+		min:          min,
+		factor:       factor,
+		recoverAfter: recoverAfter,
+		current:      base,
+	}
+}
+
+// Timeout returns the timeout currently applied to calls.
+func (e *TimeoutEscalation) Timeout() time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.current
+}
+
+// Wrap returns a Fetcher that calls fetcher under the current timeout and adjusts it based on the outcome.
+func (e *TimeoutEscalation) Wrap(fetcher Fetcher) Fetcher {
+	return func(ctx context.Context, userID string) (any, error) {
+		callCtx, cancel := context.WithTimeout(ctx, e.Timeout())
+		defer cancel()
+
+		data, err := fetcher(callCtx, userID)
+		switch {
+		case err == nil:
+			e.succeeded()
+		case ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded):
+			// Only our own timeout counts; the caller running out of budget isn't the service's fault.
+			e.timedOut()
+		}
+		return data, err
+	}
+}
+
+// timedOut shrinks the timeout one step.
+func (e *TimeoutEscalation) timedOut() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.successes = 0
+	e.current = time.Duration(float64(e.current) * e.factor)
+	if e.current < e.min {
+		e.current = e.min
+	}
+}
+
+// succeeded counts a success and raises the timeout one step every recoverAfter of them.
+func (e *TimeoutEscalation) succeeded() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.current >= e.base {
+		return
+	}
+	e.successes++
+	if e.successes < e.recoverAfter {
+		return
+	}
+	e.successes = 0
+	e.current = time.Duration(float64(e.current) / e.factor)
+	if e.current > e.base {
+		e.current = e.base
+	}
+}
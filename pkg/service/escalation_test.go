@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTimeoutEscalationShrinksAndRecovers(t *testing.T) {
+	e := NewTimeoutEscalation(40*time.Millisecond, 10*time.Millisecond, 0.5, 2)
+	SetTimeoutEscalation("test", e)
+	defer SetTimeoutEscalation("test", nil)
+
+	slow := true
+	fetch := escalated("test", func(ctx context.Context, userID string) (any, error) {
+		if slow {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return userID, nil
+	})
+
+	// Each timeout halves the timeout, down to the floor.
+	for _, want := range []time.Duration{20 * time.Millisecond, 10 * time.Millisecond, 10 * time.Millisecond} {
+		if _, err := fetch(context.Background(), "123"); err == nil {
+			t.Fatal("slow fetch succeeded")
+		}
+		if got := e.Timeout(); got != want {
+			t.Fatalf("Timeout() = %v after a timeout, want %v", got, want)
+		}
+	}
+
+	// Every 2 successes undo one step, up to the base.
+	slow = false
+	for _, want := range []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond} {
+		if _, err := fetch(context.Background(), "123"); err != nil {
+			t.Fatal(err)
+		}
+		if got := e.Timeout(); got != want {
+			t.Fatalf("Timeout() = %v after a success, want %v", got, want)
+		}
+	}
+}
+
+func TestTimeoutEscalationIgnoresCallerDeadline(t *testing.T) {
+	e := NewTimeoutEscalation(time.Second, 10*time.Millisecond, 0.5, 1)
+	fetch := e.Wrap(func(ctx context.Context, userID string) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	fetch(ctx, "123")
+	if got := e.Timeout(); got != time.Second {
+		t.Fatalf("Timeout() = %v after the caller's deadline passed, want it unchanged", got)
+	}
+}
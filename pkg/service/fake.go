@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeFetcher serves one service's data from memory instead of calling it over HTTP.
+type FakeFetcher func(userID string) (any, error)
+
+var (
+	fakesMu sync.RWMutex
+	offline bool
+	fakes   = make(map[string]FakeFetcher)
+)
+
+// RegisterFake sets the in-memory fetcher used for the named service while offline mode is on.
+func RegisterFake(name string, fn FakeFetcher) {
+	fakesMu.Lock()
+	defer fakesMu.Unlock()
+	fakes[name] = fn
+}
+
+// SetOffline switches every fetcher between real HTTP calls (false) and the registered fakes (true).
+// In offline mode a service without a fake fails instead of falling back to the network.
+func SetOffline(on bool) {
+	fakesMu.Lock()
+	defer fakesMu.Unlock()
+	offline = on
+}
+
+// offlineFetcher returns the fetcher to use instead of HTTP for name, if offline mode is on.
+func offlineFetcher(name string) (FakeFetcher, bool) {
+	fakesMu.RLock()
+	defer fakesMu.RUnlock()
+	if !offline {
+		return nil, false
+	}
+	if fn, ok := fakes[name]; ok {
+		return fn, true
+	}
+	return func(string) (any, error) {
+		return nil, fmt.Errorf("offline mode: no fake registered for service %q", name)
+	}, true
+}
+
+// SeedFakes registers fakes returning the same shapes as cmd/mock-service (without the random delays),
+// so the gateway can run end-to-end with no network, e.g. in CI. Arrays are []any, as decoded
+// JSON would be, so response transforms treat fake and real data alike.
+func SeedFakes() {
+	RegisterFake("user", func(userID string) (any, error) {
+		return map[string]any{
+			"service":   "user",
+			"id":        userID,
+			"name":      "John Doe",
+			"email":     "john@example.com",
+			"timestamp": time.Now().Unix(),
+		}, nil
+	})
+	RegisterFake("orders", func(userID string) (any, error) {
+		return map[string]any{
+			"service": "orders",
+			"userId":  userID,
+			"orders": []any{
+				map[string]any{"id": "ORD001", "total": 99.99},
+				map[string]any{"id": "ORD002", "total": 149.99},
+			},
+			"timestamp": time.Now().Unix(),
+		}, nil
+	})
+	RegisterFake("notifications", func(userID string) (any, error) {
+		return map[string]any{
+			"service":   "notifications",
+			"userId":    userID,
+			"unread":    3,
+			"messages":  []any{"Welcome back!", "Order shipped", "New feature available"},
+			"timestamp": time.Now().Unix(),
+		}, nil
+	})
+	RegisterFake("inventory", func(productID string) (any, error) {
+		return map[string]any{
+			"service":   "inventory",
+			"productId": productID,
+			"stock":     42,
+			"price":     49.99,
+			"timestamp": time.Now().Unix(),
+		}, nil
+	})
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	fallbackMu sync.RWMutex
+	fallbacks  = make(map[string]func(userID string) any)
+)
+
+// SetFallback registers fn to produce name's data when its fetch fails or times out, for
+// services where a default is an acceptable degraded answer (e.g. an empty notification list).
+// The aggregate handlers then serve fn's value and list the service under fallbacks_used
+// instead of errors, but still count it as failed: a fallback never meets ?min_success or
+// keeps the snapshot or the every-service-failed 502 from applying.
+// A nil fn removes the fallback. Services have none by default.
+func SetFallback(name string, fn func(userID string) any) {
+	fallbackMu.Lock()
+	defer fallbackMu.Unlock()
+	if fn == nil {
+		delete(fallbacks, name)
+		return
+	}
+	fallbacks[name] = fn
+}
+
+// Fallback returns the value of name's fallback for userID, if it has one.
+func Fallback(name, userID string) (any, bool) {
+	fallbackMu.RLock()
+	fn, ok := fallbacks[name]
+	fallbackMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return fn(userID), true
+}
+
+var (
+	fallbackServicesMu sync.RWMutex
+	fallbackServices   = make(map[string]string)
+)
+
+// SetFallbackService makes other stand in for name while name's circuit breaker is open, for
+// services that offer equivalent data (e.g. a read replica of the same records). The stand-in's
+// data is marked with "via": "fallback_service". Unlike SetFallback it is a real fetch, so it
+// counts as a success. An empty other removes it. Services have none by default.
+func SetFallbackService(name, other string) {
+	fallbackServicesMu.Lock()
+	defer fallbackServicesMu.Unlock()
+	if other == "" {
+		delete(fallbackServices, name)
+		return
+	}
+	fallbackServices[name] = other
+}
+
+// fallbackService returns the service standing in for name, if it has one.
+func fallbackService(name string) (string, bool) {
+	fallbackServicesMu.RLock()
+	defer fallbackServicesMu.RUnlock()
+	other, ok := fallbackServices[name]
+	return other, ok
+}
+
+// standInKey marks a fetch made on behalf of another service, so stand-ins aren't chained.
+type standInKey struct{}
+
+// viaFallbackService returns a Fetcher that calls fetcher and, when it fails fast with
+// ErrCircuitOpen, fetches from name's fallback service in Default instead (see SetFallbackService).
+func viaFallbackService(name string, fetcher Fetcher) Fetcher {
+	return func(ctx context.Context, userID string) (any, error) {
+		data, err := fetcher(ctx, userID)
+		if !errors.Is(err, ErrCircuitOpen) || ctx.Value(standInKey{}) != nil {
+			return data, err
+		}
+		other, ok := fallbackService(name)
+		if !ok {
+			return data, err
+		}
+		standIn, ok := Default.Get(other)
+		if !ok {
+			return data, err
+		}
+		otherData, otherErr := standIn(context.WithValue(ctx, standInKey{}, true), userID)
+		if otherErr != nil {
+			return nil, fmt.Errorf("%w (fallback service %s: %v)", err, other, otherErr)
+		}
+		fields, ok := otherData.(map[string]any)
+		if !ok {
+			return otherData, nil
+		}
+		// A copy: the stand-in's data may be shared with ResponseCache.
+		marked := make(map[string]any, len(fields)+1)
+		for k, v := range fields {
+			marked[k] = v
+		}
+		marked["via"] = "fallback_service"
+		return marked, nil
+	}
+}
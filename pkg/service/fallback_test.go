@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFallbackServiceAnswersWhileTheBreakerIsOpen(t *testing.T) {
+	old := Default
+	Default = NewRegistry()
+	t.Cleanup(func() { Default = old })
+	SetFallbackService("primary", "replica")
+	defer SetFallbackService("primary", "")
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker := newTestBreaker(1, time.Minute, &now)
+	primary := viaFallbackService("primary", breaker.Wrap(func(ctx context.Context, userID string) (any, error) {
+		return nil, errors.New("boom")
+	}))
+	Default.Register("primary", primary)
+	Default.Register("replica", viaFallbackService("replica", func(ctx context.Context, userID string) (any, error) {
+		return map[string]any{"id": userID, "source": "replica"}, nil
+	}))
+
+	// The failure that trips the breaker is the primary's own.
+	if _, err := primary(context.Background(), "u1"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("first call: err = %v, want the primary's failure", err)
+	}
+	if breaker.State() != StateOpen {
+		t.Fatalf("breaker = %s, want open", breaker.State())
+	}
+
+	data, err := primary(context.Background(), "u1")
+	if err != nil {
+		t.Fatalf("with the breaker open: err = %v, want the replica's data", err)
+	}
+	got := data.(map[string]any)
+	if got["source"] != "replica" || got["id"] != "u1" || got["via"] != "fallback_service" {
+		t.Fatalf("with the breaker open: data = %v, want the replica's, marked via fallback_service", got)
+	}
+
+	// Without a fallback service the open breaker's error comes through.
+	SetFallbackService("primary", "")
+	if _, err := primary(context.Background(), "u1"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("without a fallback service: err = %v, want ErrCircuitOpen", err)
+	}
+}
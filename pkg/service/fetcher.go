@@ -1,49 +1,282 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
+// transport is shared by every client below so they all draw from one connection pool.
+var transport = newPoolTransport() // counts dials and connection reuse, see PoolStats.
+
 // resty is a library for making HTTP requests in Go. It is a wrapper around the net/http package.
 // same as axios in javascript.
-var client = resty.New().
-	SetTimeout(3 * time.Second). // timeout after 3 seconds.
-	SetRetryCount(2)             // retry 2 times if the request fails.
+// Guarded by clientsMu: SetRetryPolicy swaps it. Use clientFor.
+var client = newClient(3 * time.Second) // timeout after 3 seconds.
+
+// newClient returns a resty client on the shared transport with the given per-attempt timeout
+// and the current retry policy. Caller must hold clientsMu, except during package init.
+func newClient(timeout time.Duration) *resty.Client {
+	return resty.New().
+		SetTransport(transport).
+		SetTimeout(timeout).
+		SetRetryCount(retry.maxRetries).
+		SetRetryWaitTime(retry.baseDelay).
+		SetRetryMaxWaitTime(retry.maxDelay).
+		AddRetryCondition(retryable)
+}
+
+// FetchConfig holds the per-service settings for a downstream call.
+//
+// A call has two timeouts, and fails with whichever is hit first: each attempt gets
+// AttemptTimeout (or its Payload's timeout), failing with ErrAttemptTimeout once the last
+// retry has run out of it too, and the whole call, retries and the waits between them
+// included, gets Timeout (and Share), failing with ErrBudgetExhausted.
+type FetchConfig struct {
+	// Timeout is the overall budget for the whole call, retries included. Zero leaves only the
+	// per-attempt timeout in place.
+	Timeout time.Duration
+
+	// AttemptTimeout is how long each attempt may take. Zero leaves it to Payload.
+	AttemptTimeout time.Duration
+
+	// Payload is how large the service's responses are expected to be. Without an AttemptTimeout
+	// it picks the per-attempt read timeout (see SetPayloadTimeout); empty means the client's default 3s.
+	Payload PayloadSize
+
+	// BaseURL is the scheme and host the service is reached at, e.g. "http://orders.internal:8080".
+	// Empty means DefaultBaseURL.
+	BaseURL string
+
+	// Share is the slice (0-1] of the caller's remaining deadline this service may use, so one
+	// slow service can't eat the whole request budget. Zero, or a caller without a deadline,
+	// means no slice. When both Share and Timeout are set the earlier deadline wins.
+	Share float64
+}
+
+// DefaultBaseURL is where downstream services are reached unless FetchConfig.BaseURL says otherwise.
+const DefaultBaseURL = "http://localhost:9090"
+
+// url returns path on cfg's base URL.
+func (cfg FetchConfig) url(path string) string {
+	base := cfg.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
+// ErrBudgetExhausted is returned when a call runs out of its own slice of the budget
+// (FetchConfig.Timeout or Share), its overall timeout, while the caller's context still has
+// time left. It also matches context.DeadlineExceeded.
+var ErrBudgetExhausted = errors.New("service budget exhausted")
+
+// ErrAttemptTimeout is returned when a call's last attempt runs out of its per-attempt timeout
+// (FetchConfig.AttemptTimeout or Payload) while the call's overall budget still has time left.
+// It also matches context.DeadlineExceeded.
+var ErrAttemptTimeout = errors.New("attempt timed out")
+
+// attemptClient returns the resty client enforcing cfg's per-attempt timeout.
+func (cfg FetchConfig) attemptClient() *resty.Client {
+	if cfg.AttemptTimeout > 0 {
+		return clientWithTimeout(cfg.AttemptTimeout)
+	}
+	return clientFor(cfg.Payload)
+}
+
+// deadline returns the deadline cfg allocates to a call made at now under parent.
+func (cfg FetchConfig) deadline(parent context.Context, now time.Time) (time.Time, bool) {
+	var deadline time.Time
+	if cfg.Timeout > 0 {
+		deadline = now.Add(cfg.Timeout)
+	}
+	if parentDeadline, ok := parent.Deadline(); ok && cfg.Share > 0 && cfg.Share < 1 {
+		slice := now.Add(time.Duration(float64(parentDeadline.Sub(now)) * cfg.Share))
+		if deadline.IsZero() || slice.Before(deadline) {
+			deadline = slice
+		}
+	}
+	return deadline, !deadline.IsZero()
+}
+
+var (
+	fetchConfigMu sync.RWMutex
+	fetchConfigs  = make(map[string]FetchConfig)
+)
+
+// SetFetchConfig sets the config FetchUser/FetchOrders/FetchNotifications use for the named service.
+func SetFetchConfig(name string, cfg FetchConfig) {
+	fetchConfigMu.Lock()
+	defer fetchConfigMu.Unlock()
+	fetchConfigs[name] = cfg
+}
+
+// MergeFetchConfig sets the fields of cfg that aren't zero on the named service's config,
+// leaving the rest as they were, e.g. MergeFetchConfig("orders", FetchConfig{Share: 0.5}).
+func MergeFetchConfig(name string, cfg FetchConfig) {
+	fetchConfigMu.Lock()
+	defer fetchConfigMu.Unlock()
+	merged := fetchConfigs[name]
+	if cfg.Timeout != 0 {
+		merged.Timeout = cfg.Timeout
+	}
+	if cfg.AttemptTimeout != 0 {
+		merged.AttemptTimeout = cfg.AttemptTimeout
+	}
+	if cfg.Payload != "" {
+		merged.Payload = cfg.Payload
+	}
+	if cfg.BaseURL != "" {
+		merged.BaseURL = cfg.BaseURL
+	}
+	if cfg.Share != 0 {
+		merged.Share = cfg.Share
+	}
+	fetchConfigs[name] = merged
+}
+
+// fetchConfig returns the config registered for name, or the zero FetchConfig.
+func fetchConfig(name string) FetchConfig {
+	fetchConfigMu.RLock()
+	defer fetchConfigMu.RUnlock()
+	return fetchConfigs[name]
+}
 
 // function to call api to fetch user data, from another service.
-func FetchUser(userID string) (interface{}, error) {
-	resp, err := client.R().
-		SetResult(map[string]interface{}{}).
-		Get("http://localhost:9090/mock/user/" + userID)
+// The call is aborted as soon as ctx is done.
+func FetchUser(ctx context.Context, userID string) (interface{}, error) {
+	return FetchUserWithConfig(ctx, userID, fetchConfig("user"))
+}
 
+// FetchUserWithConfig fetches user data using cfg instead of the service's registered config.
+func FetchUserWithConfig(ctx context.Context, userID string, cfg FetchConfig) (interface{}, error) {
+	return get(ctx, "user", userID, cfg.url("/mock/user/"+userID), cfg)
+}
+
+// function to call api to fetch orders data, from another service.
+// The call is aborted as soon as ctx is done.
+func FetchOrders(ctx context.Context, userID string) (interface{}, error) {
+	return FetchOrdersWithConfig(ctx, userID, fetchConfig("orders"))
+}
+
+// FetchOrdersWithConfig fetches orders data using cfg instead of the service's registered config.
+func FetchOrdersWithConfig(ctx context.Context, userID string, cfg FetchConfig) (interface{}, error) {
+	return get(ctx, "orders", userID, cfg.url("/mock/orders/"+userID), cfg)
+}
+
+// function to call api to fetch notifications data, from another service.
+// The call is aborted as soon as ctx is done.
+func FetchNotifications(ctx context.Context, userID string) (interface{}, error) {
+	return FetchNotificationsWithConfig(ctx, userID, fetchConfig("notifications"))
+}
+
+// FetchNotificationsWithConfig fetches notifications data using cfg instead of the service's registered config.
+func FetchNotificationsWithConfig(ctx context.Context, userID string, cfg FetchConfig) (interface{}, error) {
+	return get(ctx, "notifications", userID, cfg.url("/mock/notifications/"+userID), cfg)
+}
+
+// get calls a downstream service and records how long the call took (retries included) and whether it failed in Stats.
+// A paused service isn't called at all (see Pause), and in offline mode the registered fake
+// for name answers instead (see SetOffline). Real calls need room in the service's bulkhead (see SetBulkhead)
+// and then wait for an outbound slot (see SetMaxConcurrency).
+func get(ctx context.Context, name, userID, url string, cfg FetchConfig) (interface{}, error) {
+	return send(ctx, name, userID, http.MethodGet, url, nil, cfg)
+}
+
+// send makes one downstream call with method, JSON-encoding body when it isn't nil, and is
+// what get is built on. Writes are never answered for a paused service or by the offline
+// fakes, which only serve reads: both fail with ErrReadOnly. A call redirected with WithUpstream
+// goes to that host instead of url's and isn't recorded in Stats.
+func send(ctx context.Context, name, userID, method, url string, body any, cfg FetchConfig) (interface{}, error) {
+	if IsPaused(name) {
+		if method != http.MethodGet {
+			return nil, fmt.Errorf("%w: %s is paused for maintenance", ErrReadOnly, name)
+		}
+		return MaintenanceResult(name), nil
+	}
+
+	start := time.Now()
+	if fake, ok := offlineFetcher(name); ok {
+		if method != http.MethodGet {
+			return nil, fmt.Errorf("%w: offline mode", ErrReadOnly)
+		}
+		data, err := fake(userID)
+		Stats.Record(name, time.Since(start), err)
+		return data, err
+	}
+
+	// The service's budget goes on the request context rather than the client so it covers
+	// every retry: resty stops retrying once the context is done.
+	parent := ctx
+	if deadline, ok := cfg.deadline(parent, start); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(parent, deadline)
+		defer cancel()
+	}
+
+	leave, err := enterBulkhead(name)
 	if err != nil {
 		return nil, err
 	}
-	return resp.Result(), nil
-}
+	defer leave()
 
-// function to call api to fetch orders data, from another service.
-func FetchOrders(userID string) (interface{}, error) {
-	resp, err := client.R().
-		SetResult(map[string]interface{}{}).
-		Get("http://localhost:9090/mock/orders/" + userID)
+	release, err := acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	start = time.Now() // time spent queued for a slot isn't the downstream's latency
+
+	url, err = upstreamURL(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	data, err := doerFor(name, cfg).Do(ctx, method, url, body)
+	switch {
+	case err == nil || parent.Err() != nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("%w: %w", ErrBudgetExhausted, context.DeadlineExceeded)
+	case ctx.Err() == nil && attemptTimedOut(err):
+		err = fmt.Errorf("%w: %w: %v", ErrAttemptTimeout, context.DeadlineExceeded, err)
+	}
+	if Upstream(ctx) == nil {
+		Stats.Record(name, time.Since(start), err)
+	}
 
 	if err != nil {
 		return nil, err
 	}
-	return resp.Result(), nil
+	return data, nil
 }
 
-// function to call api to fetch notifications data, from another service.
-func FetchNotifications(userID string) (interface{}, error) {
-	resp, err := client.R().
-		SetResult(map[string]interface{}{}).
-		Get("http://localhost:9090/mock/notifications/" + userID)
+// attemptTimedOut reports whether err is an HTTP client timing out one attempt.
+func attemptTimedOut(err error) bool {
+	var netErr net.Error
+	return !errors.Is(err, ErrDNS) && errors.As(err, &netErr) && netErr.Timeout()
+}
 
+// decode turns a downstream response into its JSON object.
+// The body is decoded by hand rather than with resty's SetResult, which silently leaves an
+// empty result for non-JSON content types and error statuses - both would look like a success.
+func decode(resp *resty.Response, err error) (map[string]interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	return resp.Result(), nil
+	if resp.IsError() {
+		return nil, fmt.Errorf("%w: %s", ErrBadStatus, resp.Status())
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(resp.Body(), &data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParse, err)
+	}
+	return data, nil
 }
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMalformedResponsesFailWithTheirCategory(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string // Category of the error, "" for success
+	}{
+		{"valid JSON", http.StatusOK, `{"id": "1"}`, ""},
+		{"invalid JSON", http.StatusOK, `{"id": `, "parse"},
+		{"not an object", http.StatusOK, `["id"]`, "parse"},
+		{"empty body", http.StatusOK, ``, "parse"},
+		{"error status", http.StatusNotFound, `{"error": "no such user"}`, "status"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			data, err := (restyDoer{client: clientFor(""), name: "malformed"}).Do(context.Background(), http.MethodGet, srv.URL, nil)
+			if got := Category(err); got != tt.want {
+				t.Fatalf("Do() = %v, %v; category %q, want %q", data, err, got, tt.want)
+			}
+			if err != nil && data != nil {
+				t.Fatalf("Do() = %v with %v, want no data", data, err)
+			}
+		})
+	}
+}
+
+func TestFetcherTimesOutOnItsOwnTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer srv.Close()
+	SetFetchConfig("timeout-fast", FetchConfig{BaseURL: srv.URL, Timeout: 10 * time.Millisecond})
+	SetFetchConfig("timeout-slow", FetchConfig{BaseURL: srv.URL, Timeout: time.Second})
+	defer SetFetchConfig("timeout-fast", FetchConfig{})
+	defer SetFetchConfig("timeout-slow", FetchConfig{})
+
+	start := time.Now()
+	_, err := HTTPFetcher("timeout-fast", "/users/")(context.Background(), "1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("10ms timeout against a 100ms service: err = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Fatalf("timed out after %v, want well under the service's 100ms", elapsed)
+	}
+
+	// The same service is fine for one with a longer timeout.
+	if _, err := HTTPFetcher("timeout-slow", "/users/")(context.Background(), "1"); err != nil {
+		t.Fatalf("1s timeout against a 100ms service: err = %v, want success", err)
+	}
+}
+
+func TestCancellingTheCallerAbortsTheRequest(t *testing.T) {
+	arrived := make(chan struct{})
+	aborted := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		<-r.Context().Done() // blocks until the gateway drops the connection
+		close(aborted)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := FetchUserWithConfig(ctx, "1", FetchConfig{BaseURL: srv.URL})
+		errc <- err
+	}()
+
+	<-arrived
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("fetch still running a second after its context was cancelled")
+	}
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("the downstream request was left open after the caller gave up")
+	}
+}
+
+func TestTinyBudgetSliceTimesOutWhileLargerOnesSucceed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	tests := []struct {
+		service string
+		share   float64
+		wantErr bool
+	}{
+		{"share-tiny", 0.01, true}, // 10ms of the second left
+		{"share-half", 0.5, false},
+		{"share-all", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			_, err := get(ctx, tt.service, "1", srv.URL, FetchConfig{Share: tt.share})
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("err = %v, want success", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrBudgetExhausted) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("err = %v, want ErrBudgetExhausted", err)
+			}
+		})
+	}
+	if ctx.Err() != nil {
+		t.Fatal("the caller's own deadline ran out; the slices should have been all that expired")
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// hopByHop are the headers that describe a single connection and must never be forwarded.
+var hopByHop = map[string]bool{
+	"Connection":          true,
+	"Keep-Alive":          true,
+	"Proxy-Authenticate":  true,
+	"Proxy-Authorization": true,
+	"Proxy-Connection":    true,
+	"Te":                  true,
+	"Trailer":             true,
+	"Transfer-Encoding":   true,
+	"Upgrade":             true,
+}
+
+var (
+	forwardMu      sync.RWMutex
+	forwardHeaders []string // canonical names
+)
+
+// SetForwardHeaders sets which inbound headers are copied onto every downstream call,
+// e.g. SetForwardHeaders([]string{"Authorization", "X-Trace-Id"}). Hop-by-hop headers
+// such as Connection are ignored even if listed. It replaces any previous list.
+func SetForwardHeaders(names []string) {
+	canonical := make([]string, 0, len(names))
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(name)
+		if !hopByHop[name] {
+			canonical = append(canonical, name)
+		}
+	}
+
+	forwardMu.Lock()
+	defer forwardMu.Unlock()
+	forwardHeaders = canonical
+}
+
+type forwardKey struct{}
+
+// WithForwardedHeaders returns a copy of ctx carrying the whitelisted headers from h
+// (see SetForwardHeaders). Headers the inbound Connection header marks as hop-by-hop are dropped too.
+func WithForwardedHeaders(ctx context.Context, h http.Header) context.Context {
+	forwardMu.RLock()
+	names := forwardHeaders
+	forwardMu.RUnlock()
+
+	connection := make(map[string]bool)
+	for _, v := range h.Values("Connection") {
+		for _, name := range strings.Split(v, ",") {
+			connection[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+
+	out := make(http.Header)
+	for _, name := range names {
+		if vs := h.Values(name); len(vs) > 0 && !connection[name] {
+			out[name] = append([]string(nil), vs...)
+		}
+	}
+	return context.WithValue(ctx, forwardKey{}, out)
+}
+
+// TrimForwardedHeaders returns a copy of ctx whose forwarded headers (see WithForwardedHeaders)
+// take up at most maxBytes on the wire, counting each value as "Name: value\r\n", and the names of
+// the headers it dropped to get there. Headers are kept in SetForwardHeaders' order, so list the
+// ones downstreams can't do without first; one that doesn't fit is dropped whole, with its values.
+func TrimForwardedHeaders(ctx context.Context, maxBytes int) (context.Context, []string) {
+	h := forwardedHeaders(ctx)
+	forwardMu.RLock()
+	names := forwardHeaders
+	forwardMu.RUnlock()
+
+	out := make(http.Header, len(h))
+	var dropped []string
+	size := 0
+	for _, name := range names {
+		vs, ok := h[name]
+		if !ok {
+			continue
+		}
+		n := 0
+		for _, v := range vs {
+			n += len(name) + len(": \r\n") + len(v)
+		}
+		if size+n > maxBytes {
+			dropped = append(dropped, name)
+			continue
+		}
+		size += n
+		out[name] = vs
+	}
+	if len(dropped) == 0 {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, forwardKey{}, out), dropped
+}
+
+// forwardedHeaders returns the headers stored in ctx by WithForwardedHeaders.
+func forwardedHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(forwardKey{}).(http.Header)
+	return h
+}
+
+// credentialHeaders are the forwarded headers that identify the caller to a downstream service.
+var credentialHeaders = []string{"Authorization", "Cookie"}
+
+// credentials returns the forwarded credential headers in ctx as one string, so calls made on
+// behalf of different callers can be told apart (see Coalesce).
+func credentials(ctx context.Context) string {
+	h := forwardedHeaders(ctx)
+	var b strings.Builder
+	for _, name := range credentialHeaders {
+		for _, v := range h.Values(name) {
+			b.WriteString(name)
+			b.WriteByte(':')
+			b.WriteString(v)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTrimForwardedHeaders(t *testing.T) {
+	SetForwardHeaders([]string{"Authorization", "X-Trace-Id", "X-Tenant"})
+	t.Cleanup(func() { SetForwardHeaders(nil) })
+
+	in := http.Header{}
+	in.Set("Authorization", "Bearer token")        // 35 bytes as "Authorization: Bearer token\r\n"
+	in.Set("X-Trace-Id", strings.Repeat("t", 100)) // 114 bytes
+	in.Set("X-Tenant", "acme")                     // 16 bytes
+	ctx := WithForwardedHeaders(context.Background(), in)
+
+	tests := []struct {
+		name     string
+		maxBytes int
+		want     []string
+		dropped  []string
+	}{
+		{"all fit", 165, []string{"Authorization", "X-Tenant", "X-Trace-Id"}, nil},
+		{"oversized one dropped, later ones kept", 100, []string{"Authorization", "X-Tenant"}, []string{"X-Trace-Id"}},
+		{"none fit", 10, nil, []string{"Authorization", "X-Trace-Id", "X-Tenant"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trimmed, dropped := TrimForwardedHeaders(ctx, tt.maxBytes)
+			if !slices.Equal(dropped, tt.dropped) {
+				t.Fatalf("dropped = %v, want %v", dropped, tt.dropped)
+			}
+			h := forwardedHeaders(trimmed)
+			var kept []string
+			size := 0
+			for name, vs := range h {
+				kept = append(kept, name)
+				for _, v := range vs {
+					size += len(name) + len(": \r\n") + len(v)
+				}
+			}
+			slices.Sort(kept)
+			if !slices.Equal(kept, tt.want) || size > tt.maxBytes {
+				t.Fatalf("kept %v (%d bytes), want %v within %d", kept, size, tt.want, tt.maxBytes)
+			}
+		})
+	}
+}
+
+func TestOnlyWhitelistedHeadersReachTheDownstream(t *testing.T) {
+	SetForwardHeaders([]string{"Authorization", "X-Trace-Id", "Keep-Alive"})
+	t.Cleanup(func() { SetForwardHeaders(nil) })
+
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer srv.Close()
+
+	in := http.Header{}
+	in.Set("Authorization", "Bearer token")
+	in.Set("X-Trace-Id", "trace-1")
+	in.Set("X-Secret", "not whitelisted")
+	in.Set("Keep-Alive", "timeout=5") // whitelisted, but hop-by-hop
+	in.Set("Connection", "Keep-Alive")
+	ctx := WithForwardedHeaders(context.Background(), in)
+	if _, err := FetchUserWithConfig(ctx, "1", FetchConfig{BaseURL: srv.URL}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Get("Authorization") != "Bearer token" || got.Get("X-Trace-Id") != "trace-1" {
+		t.Fatalf("downstream headers = %v, want Authorization and X-Trace-Id", got)
+	}
+	for _, name := range []string{"X-Secret", "Keep-Alive"} {
+		if v := got.Get(name); v != "" {
+			t.Fatalf("downstream got %s: %q, want it dropped", name, v)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// Freshness says where the data a service contributed to a response came from.
+type Freshness string
+
+const (
+	FreshLive     Freshness = "live"     // fetched from the service for this request
+	FreshCache    Freshness = "cache"    // served from ResponseCache within its TTL
+	FreshStale    Freshness = "stale"    // served from ResponseCache past its TTL, because the fetch failed
+	FreshFallback Freshness = "fallback" // the service's fallback data (see SetFallback)
+)
+
+// FreshnessLog collects, during one request, which services were answered from the cache rather
+// than live (see Cache.Wrap).
+type FreshnessLog struct {
+	mu     sync.Mutex
+	states map[string]Freshness
+}
+
+type freshnessLogKey struct{}
+
+// WithFreshnessLog returns a copy of ctx that records into a new FreshnessLog how every fetch made
+// with it was answered.
+func WithFreshnessLog(ctx context.Context) (context.Context, *FreshnessLog) {
+	log := &FreshnessLog{states: make(map[string]Freshness)}
+	return context.WithValue(ctx, freshnessLogKey{}, log), log
+}
+
+// Of returns how the named service was answered: FreshLive unless the cache said otherwise.
+func (l *FreshnessLog) Of(name string) Freshness {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if f, ok := l.states[name]; ok {
+		return f
+	}
+	return FreshLive
+}
+
+// recordFreshness notes in ctx's FreshnessLog, if it has one, how name was answered.
+func recordFreshness(ctx context.Context, name string, f Freshness) {
+	log, ok := ctx.Value(freshnessLogKey{}).(*FreshnessLog)
+	if !ok {
+		return
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.states[name] = f
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HealthScoreConfig controls how a service's 0-100 health score is computed.
+//
+//	errorScore   = 100 * (1 - errorRate)
+//	latencyScore = 100 while p95 <= SLO, then 100 * SLO / p95
+//	score        = (ErrorWeight*errorScore + LatencyWeight*latencyScore) / (ErrorWeight + LatencyWeight)
+type HealthScoreConfig struct {
+	ErrorWeight   float64
+	LatencyWeight float64
+	DefaultSLO    time.Duration            // p95 latency target for services without their own entry
+	SLOs          map[string]time.Duration // per-service p95 latency targets
+}
+
+// HealthScore is the computed health of one service over a window.
+type HealthScore struct {
+	Service   string  `json:"service"`
+	Score     int     `json:"score"`
+	Calls     int     `json:"calls"`
+	ErrorRate float64 `json:"error_rate"`
+	P95Ms     float64 `json:"p95_ms"`
+	SLOMs     float64 `json:"slo_ms"`
+}
+
+var (
+	healthScoreMu  sync.RWMutex
+	healthScoreCfg = DefaultHealthScoreConfig()
+)
+
+// DefaultHealthScoreConfig returns the configuration HealthScores uses until SetHealthScoreConfig
+// is called: errors weigh 0.6 and latency 0.4, against a 500ms p95 SLO for every service.
+func DefaultHealthScoreConfig() HealthScoreConfig {
+	return HealthScoreConfig{
+		ErrorWeight:   0.6,
+		LatencyWeight: 0.4,
+		DefaultSLO:    500 * time.Millisecond,
+	}
+}
+
+// SetHealthScoreConfig replaces the weights and SLOs used by HealthScores.
+func SetHealthScoreConfig(cfg HealthScoreConfig) {
+	healthScoreMu.Lock()
+	defer healthScoreMu.Unlock()
+	healthScoreCfg = cfg
+}
+
+// HealthScores scores every service seen by Stats over the last window using the configured weights.
+func HealthScores(window time.Duration) []HealthScore {
+	healthScoreMu.RLock()
+	cfg := healthScoreCfg
+	healthScoreMu.RUnlock()
+
+	return Stats.HealthScores(window, cfg)
+}
+
+// HealthScores scores every service with at least one call in the last window, sorted by service name.
+func (s *LatencyStats) HealthScores(window time.Duration, cfg HealthScoreConfig) []HealthScore {
+	s.mu.Lock()
+	cutoff := s.now().Add(-window).Truncate(s.width)
+	calls := make(map[string]int)
+	errs := make(map[string]int)
+	samples := make(map[string][]time.Duration)
+	for _, b := range s.buckets {
+		if b.samples == nil || b.start.Before(cutoff) {
+			continue
+		}
+		for name, n := range b.calls {
+			calls[name] += n
+			errs[name] += b.errors[name]
+			samples[name] = append(samples[name], b.samples[name]...)
+		}
+	}
+	s.mu.Unlock()
+
+	out := make([]HealthScore, 0, len(calls))
+	for name, n := range calls {
+		slo := cfg.DefaultSLO
+		if d, ok := cfg.SLOs[name]; ok {
+			slo = d
+		}
+		errorRate := float64(errs[name]) / float64(n)
+		p95 := percentile(samples[name], 0.95)
+		out = append(out, HealthScore{
+			Service:   name,
+			Score:     score(errorRate, p95, slo, cfg),
+			Calls:     n,
+			ErrorRate: errorRate,
+			P95Ms:     p95,
+			SLOMs:     float64(slo) / float64(time.Millisecond),
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Service < out[j].Service })
+	return out
+}
+
+// score combines an error rate and p95 latency (ms) into a 0-100 health score.
+func score(errorRate, p95Ms float64, slo time.Duration, cfg HealthScoreConfig) int {
+	errorScore := 100 * (1 - errorRate)
+
+	latencyScore := 100.0
+	sloMs := float64(slo) / float64(time.Millisecond)
+	if sloMs > 0 && p95Ms > sloMs {
+		latencyScore = 100 * sloMs / p95Ms
+	}
+
+	total := cfg.ErrorWeight + cfg.LatencyWeight
+	if total <= 0 {
+		return 0
+	}
+	return int(math.Round((cfg.ErrorWeight*errorScore + cfg.LatencyWeight*latencyScore) / total))
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthScoresFollowTheWeights(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewLatencyStats(time.Minute, 60)
+	s.now = func() time.Time { return now }
+
+	// Fast but failing half its calls: errorScore 50, latencyScore 100.
+	for i := range 10 {
+		var err error
+		if i%2 == 0 {
+			err = errors.New("boom")
+		}
+		s.RecordAt("orders", 100*time.Millisecond, err, now)
+	}
+
+	cases := []struct {
+		name          string
+		errorWeight   float64
+		latencyWeight float64
+		want          int
+	}{
+		{"default weights", 0.6, 0.4, 70},
+		{"errors only", 1, 0, 50},
+		{"latency only", 0, 1, 100},
+		{"equal weights", 1, 1, 75},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := HealthScoreConfig{ErrorWeight: tc.errorWeight, LatencyWeight: tc.latencyWeight, DefaultSLO: 500 * time.Millisecond}
+			scores := s.HealthScores(time.Hour, cfg)
+			if len(scores) != 1 || scores[0].Score != tc.want {
+				t.Fatalf("HealthScores = %+v, want orders scored %d", scores, tc.want)
+			}
+		})
+	}
+}
+
+func TestHealthScoresUseEachServiceSLO(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := NewLatencyStats(time.Minute, 60)
+	s.now = func() time.Time { return now }
+	s.RecordAt("orders", time.Second, nil, now)
+	s.RecordAt("user", time.Second, nil, now)
+
+	// 1s against 500ms halves the latency score; orders' own 2s SLO is met.
+	cfg := HealthScoreConfig{
+		LatencyWeight: 1,
+		DefaultSLO:    500 * time.Millisecond,
+		SLOs:          map[string]time.Duration{"orders": 2 * time.Second},
+	}
+	scores := s.HealthScores(time.Hour, cfg)
+	if len(scores) != 2 || scores[0].Service != "orders" || scores[0].Score != 100 || scores[1].Score != 50 {
+		t.Fatalf("HealthScores = %+v, want orders 100 and user 50", scores)
+	}
+}
@@ -0,0 +1,21 @@
+package service
+
+import "context"
+
+// HopsHeader counts how many times a request has passed through a gateway. Every downstream call
+// carries the inbound count plus one, so a downstream misconfigured to point back at the gateway
+// makes the count grow on each lap until the gateway rejects it (see middleware.LoopGuard).
+const HopsHeader = "X-Gateway-Hops"
+
+type hopsKey struct{}
+
+// WithHops returns a copy of ctx carrying the inbound request's hop count.
+func WithHops(ctx context.Context, hops int) context.Context {
+	return context.WithValue(ctx, hopsKey{}, hops)
+}
+
+// Hops returns the hop count stored in ctx by WithHops, or 0.
+func Hops(ctx context.Context) int {
+	hops, _ := ctx.Value(hopsKey{}).(int)
+	return hops
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrConcurrencyTimeout is returned when the context ends while a fetch is still queued for an
+// outbound slot (see SetMaxConcurrency). It wraps the context's error.
+var ErrConcurrencyTimeout = errors.New("timed out waiting for an outbound concurrency slot")
+
+type priorityKey struct{}
+
+// WithPriority returns a copy of ctx whose downstream calls are ranked priority when they queue for
+// an outbound slot (see SetMaxConcurrency). Higher goes first; calls without one rank 0.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// Priority returns the priority stored in ctx by WithPriority, or 0 for none.
+func Priority(ctx context.Context) int {
+	priority, _ := ctx.Value(priorityKey{}).(int)
+	return priority
+}
+
+var (
+	limiterMu sync.RWMutex
+	outbound  *slotLimiter // nil means unlimited
+)
+
+// SetMaxConcurrency caps how many downstream calls may be in flight at once across all
+// requests and services. n <= 0 removes the cap.
+//
+// Calls over the cap queue for a slot by their context's priority (see WithPriority), then by
+// deadline, soonest first, then in arrival order, so under contention the calls that matter most
+// and the ones about to run out of time get the slots first.
+//
+// Calls already holding a slot release it to the limiter they got it from, so changing
+// the limit at runtime is safe; the new limit applies to calls that start afterwards.
+func SetMaxConcurrency(n int) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	if n <= 0 {
+		outbound = nil
+		return
+	}
+	outbound = &slotLimiter{capacity: n}
+}
+
+// acquireSlot blocks until an outbound slot is free or ctx is done.
+// The returned release func must be called once the downstream call has finished.
+func acquireSlot(ctx context.Context) (release func(), err error) {
+	limiterMu.RLock()
+	l := outbound
+	limiterMu.RUnlock()
+
+	if l == nil {
+		return func() {}, nil
+	}
+	if err := l.acquire(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrConcurrencyTimeout, err)
+	}
+	return l.release, nil
+}
+
+// slotLimiter hands out capacity slots, queueing callers over that in a slotQueue.
+type slotLimiter struct {
+	mu       sync.Mutex
+	capacity int
+	active   int
+	queue    slotQueue
+	seq      uint64
+}
+
+// acquire blocks until a slot is free or ctx is done. Every nil return must be paired with a release.
+func (l *slotLimiter) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	if l.active < l.capacity && l.queue.Len() == 0 {
+		l.active++
+		l.mu.Unlock()
+		return nil
+	}
+	l.seq++
+	w := &slotWaiter{priority: Priority(ctx), seq: l.seq, ready: make(chan struct{})}
+	w.deadline, _ = ctx.Deadline()
+	heap.Push(&l.queue, w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Handed a slot while we were giving up.
+			l.releaseLocked()
+		default:
+			heap.Remove(&l.queue, w.index)
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees a slot, handing it straight to the first waiter if there is one.
+func (l *slotLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked()
+}
+
+// releaseLocked is release without locking. Caller must hold l.mu.
+func (l *slotLimiter) releaseLocked() {
+	if l.queue.Len() == 0 {
+		l.active--
+		return
+	}
+	w := heap.Pop(&l.queue).(*slotWaiter)
+	close(w.ready)
+}
+
+// slotWaiter is one call queued for a slot. ready is closed when it gets one.
+type slotWaiter struct {
+	priority int
+	deadline time.Time // zero for none
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// slotQueue is a heap of waiters: highest priority first, then soonest deadline (none last),
+// then by arrival.
+type slotQueue []*slotWaiter
+
+func (q slotQueue) Len() int { return len(q) }
+
+func (q slotQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if !a.deadline.Equal(b.deadline) {
+		switch {
+		case a.deadline.IsZero():
+			return false
+		case b.deadline.IsZero():
+			return true
+		default:
+			return a.deadline.Before(b.deadline)
+		}
+	}
+	return a.seq < b.seq
+}
+
+func (q slotQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *slotQueue) Push(x any) {
+	w := x.(*slotWaiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *slotQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer is a fake downstream recording the most requests it had in flight at once.
+// Each request is held until release is closed or hold passes.
+type countingServer struct {
+	*httptest.Server
+	inFlight, peak atomic.Int32
+	hold           time.Duration
+	release        chan struct{}
+}
+
+func newCountingServer(t *testing.T, hold time.Duration) *countingServer {
+	s := &countingServer{hold: hold, release: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		for {
+			peak := s.peak.Load()
+			if n <= peak || s.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		select {
+		case <-time.After(s.hold):
+		case <-s.release:
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(func() {
+		close(s.release)
+		s.Close()
+	})
+	return s
+}
+
+func TestMaxConcurrencyCapsInFlightCalls(t *testing.T) {
+	srv := newCountingServer(t, 20*time.Millisecond)
+	SetFetchConfig("limit-test", FetchConfig{BaseURL: srv.URL})
+	SetMaxConcurrency(3)
+	defer SetMaxConcurrency(0)
+
+	fetch := HTTPFetcher("limit-test", "/x/")
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := fetch(context.Background(), "123"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := srv.peak.Load(); peak > 3 {
+		t.Fatalf("%d calls in flight at once, want at most 3", peak)
+	} else if peak < 2 {
+		t.Fatalf("%d calls in flight at once, want the limit used", peak)
+	}
+}
+
+func TestMaxConcurrencyTimesOutQueuedCalls(t *testing.T) {
+	srv := newCountingServer(t, time.Minute)
+	SetFetchConfig("limit-test", FetchConfig{BaseURL: srv.URL})
+	SetMaxConcurrency(1)
+	defer SetMaxConcurrency(0)
+
+	fetch := HTTPFetcher("limit-test", "/x/")
+	holder, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fetch(holder, "123")
+	for srv.inFlight.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancelQueued := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancelQueued()
+	if _, err := fetch(ctx, "123"); !errors.Is(err, ErrConcurrencyTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("queued fetch returned %v, want %v", err, ErrConcurrencyTimeout)
+	}
+}
+
+func BenchmarkAcquireSlot(b *testing.B) {
+	SetMaxConcurrency(8)
+	defer SetMaxConcurrency(0)
+	ctx := context.Background()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			release, err := acquireSlot(ctx)
+			if err != nil {
+				b.Fatal(err)
+			}
+			release()
+		}
+	})
+}
+
+// waitForSlotWaiters waits until n calls are queued for an outbound slot.
+func waitForSlotWaiters(t *testing.T, n int) {
+	t.Helper()
+	limiterMu.RLock()
+	l := outbound
+	limiterMu.RUnlock()
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		l.mu.Lock()
+		queued := l.queue.Len()
+		l.mu.Unlock()
+		if queued == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d calls queued, want %d", queued, n)
+		}
+	}
+}
+
+func TestQueuedCallsGetSlotsByPriorityThenDeadline(t *testing.T) {
+	SetMaxConcurrency(1)
+	defer SetMaxConcurrency(0)
+
+	release, err := acquireSlot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Queued in this order: low priority, high priority with a far deadline, high priority with
+	// a near one, and a second low one.
+	far, cancelFar := context.WithTimeout(WithPriority(context.Background(), 2), time.Minute)
+	defer cancelFar()
+	near, cancelNear := context.WithTimeout(WithPriority(context.Background(), 2), 30*time.Second)
+	defer cancelNear()
+	calls := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{"low-1", context.Background()},
+		{"high-far", far},
+		{"high-near", near},
+		{"low-2", context.Background()},
+	}
+
+	order := make(chan string, len(calls))
+	for i, call := range calls {
+		go func() {
+			release, err := acquireSlot(call.ctx)
+			if err != nil {
+				t.Errorf("%s: %v", call.name, err)
+				return
+			}
+			order <- call.name
+			release()
+		}()
+		waitForSlotWaiters(t, i+1)
+	}
+
+	// Each call releases its slot to the next as soon as it has it.
+	release()
+	want := []string{"high-near", "high-far", "low-1", "low-2"}
+	for i, name := range want {
+		if got := <-order; got != name {
+			t.Fatalf("call %d to get a slot = %s, want %s (order %v)", i+1, got, name, want)
+		}
+	}
+}
+
+func TestPriorityDefaultsToZero(t *testing.T) {
+	if got := Priority(context.Background()); got != 0 {
+		t.Fatalf("Priority = %d, want 0", got)
+	}
+	if got := Priority(WithPriority(context.Background(), 3)); got != 3 {
+		t.Fatalf("Priority = %d, want 3", got)
+	}
+}
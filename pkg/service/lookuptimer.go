@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// LookupTimer adds up the time spent looking up the response cache during one request.
+type LookupTimer struct {
+	mu    sync.Mutex
+	total time.Duration
+}
+
+type lookupTimerKey struct{}
+
+// WithLookupTimer returns a copy of ctx that times into a new LookupTimer the cache lookups of
+// every fetch made with it (see Cache.Wrap).
+func WithLookupTimer(ctx context.Context) (context.Context, *LookupTimer) {
+	timer := &LookupTimer{}
+	return context.WithValue(ctx, lookupTimerKey{}, timer), timer
+}
+
+// Total returns the time spent in cache lookups so far, summed over the request's fetches.
+// Fetches run concurrently, so it can exceed the wall time they took.
+func (t *LookupTimer) Total() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// recordLookup adds d to ctx's LookupTimer, if it has one.
+func recordLookup(ctx context.Context, d time.Duration) {
+	timer, ok := ctx.Value(lookupTimerKey{}).(*LookupTimer)
+	if !ok {
+		return
+	}
+	timer.mu.Lock()
+	defer timer.mu.Unlock()
+	timer.total += d
+}
@@ -0,0 +1,37 @@
+package service
+
+import "sync"
+
+var (
+	pausedMu sync.RWMutex
+	paused   = make(map[string]bool)
+)
+
+// Pause stops calling the named service; its fetches return MaintenanceResult until Resume.
+func Pause(name string) {
+	pausedMu.Lock()
+	defer pausedMu.Unlock()
+	paused[name] = true
+}
+
+// Resume lets calls to a paused service through again.
+func Resume(name string) {
+	pausedMu.Lock()
+	defer pausedMu.Unlock()
+	delete(paused, name)
+}
+
+// IsPaused reports whether the named service is in a maintenance window.
+func IsPaused(name string) bool {
+	pausedMu.RLock()
+	defer pausedMu.RUnlock()
+	return paused[name]
+}
+
+// MaintenanceResult is the data returned in place of a paused service's response.
+func MaintenanceResult(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"service":     name,
+		"maintenance": true,
+	}
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// PayloadSize is the expected response size class of a downstream service.
+type PayloadSize string
+
+const (
+	PayloadSmall  PayloadSize = "small"  // metadata-sized objects; should answer quickly
+	PayloadMedium PayloadSize = "medium" // same budget as the default client
+	PayloadLarge  PayloadSize = "large"  // long lists that take a while to stream
+)
+
+var (
+	clientsMu      sync.RWMutex // guards client, payloadClients and attemptClients
+	payloadClients = map[PayloadSize]*resty.Client{
+		PayloadSmall:  newClient(1 * time.Second),
+		PayloadMedium: newClient(3 * time.Second),
+		PayloadLarge:  newClient(10 * time.Second),
+	}
+	attemptClients = make(map[time.Duration]*resty.Client) // by FetchConfig.AttemptTimeout
+)
+
+// SetPayloadTimeout sets the per-attempt read timeout for services configured with size
+// (see FetchConfig.Payload). Defaults are 1s for small, 3s for medium and 10s for large.
+//
+// A fresh client is swapped in rather than retiming the current one, so calls already in
+// flight keep the timeout they started with.
+func SetPayloadTimeout(size PayloadSize, timeout time.Duration) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	payloadClients[size] = newClient(timeout)
+}
+
+// PayloadTimeout returns the per-attempt read timeout used for size, or the default
+// client's timeout if size has none.
+func PayloadTimeout(size PayloadSize) time.Duration {
+	return clientFor(size).GetClient().Timeout
+}
+
+// clientWithTimeout returns the client with per-attempt timeout d, creating it on first use.
+func clientWithTimeout(d time.Duration) *resty.Client {
+	clientsMu.RLock()
+	c, ok := attemptClients[d]
+	clientsMu.RUnlock()
+	if ok {
+		return c
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if c, ok := attemptClients[d]; ok {
+		return c
+	}
+	c = newClient(d)
+	attemptClients[d] = c
+	return c
+}
+
+// clientFor returns the client for size, falling back to the default client.
+func clientFor(size PayloadSize) *resty.Client {
+	clientsMu.RLock()
+	defer clientsMu.RUnlock()
+	if c, ok := payloadClients[size]; ok {
+		return c
+	}
+	return client
+}
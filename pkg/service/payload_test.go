@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPayloadSizePicksTheReadTimeout(t *testing.T) {
+	if small, large := PayloadTimeout(PayloadSmall), PayloadTimeout(PayloadLarge); small >= large {
+		t.Fatalf("default timeouts: small %v, large %v, want small shorter", small, large)
+	}
+
+	SetRetryPolicy(time.Millisecond, time.Millisecond, 0)
+	SetPayloadTimeout(PayloadSmall, 20*time.Millisecond)
+	SetPayloadTimeout(PayloadLarge, time.Second)
+	t.Cleanup(func() {
+		SetPayloadTimeout(PayloadSmall, time.Second)
+		SetPayloadTimeout(PayloadLarge, 10*time.Second)
+		SetRetryPolicy(100*time.Millisecond, 2*time.Second, 2)
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1"}`))
+	}))
+	defer srv.Close()
+
+	_, err := get(context.Background(), "payload-small", "1", srv.URL, FetchConfig{Payload: PayloadSmall})
+	if !errors.Is(err, ErrAttemptTimeout) {
+		t.Fatalf("small payload against a 100ms service: err = %v, want ErrAttemptTimeout", err)
+	}
+	if _, err := get(context.Background(), "payload-large", "1", srv.URL, FetchConfig{Payload: PayloadLarge}); err != nil {
+		t.Fatalf("large payload against a 100ms service: err = %v, want success", err)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// ErrPinMismatch marks a TLS connection whose certificate chain carries none of the host's pinned keys.
+var ErrPinMismatch = errors.New("tls certificate does not match pinned key")
+
+var (
+	pinMu sync.RWMutex
+	pins  = make(map[string]map[string]bool) // host -> SPKI fingerprints
+)
+
+// SetPinnedKeys pins host to the given public keys: TLS connections to it are rejected unless
+// some certificate in a verified chain has one of them, even if the chain is otherwise trusted.
+// Each fingerprint is the hex SHA-256 of a certificate's SubjectPublicKeyInfo (colons allowed),
+// e.g. from `openssl x509 -pubkey -noout | openssl pkey -pubin -outform der | sha256sum`.
+// No fingerprints removes the host's pins.
+func SetPinnedKeys(host string, fingerprints ...string) {
+	pinMu.Lock()
+	defer pinMu.Unlock()
+	if len(fingerprints) == 0 {
+		delete(pins, host)
+		return
+	}
+	set := make(map[string]bool, len(fingerprints))
+	for _, fp := range fingerprints {
+		set[normalizeFingerprint(fp)] = true
+	}
+	pins[host] = set
+}
+
+// normalizeFingerprint lowercases fp and strips colons.
+func normalizeFingerprint(fp string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fp), ":", ""))
+}
+
+// tlsConfig is the base TLS config for downstream connections; dialTLS clones it per connection.
+var tlsConfig = &tls.Config{}
+
+// dialTLS returns a DialTLSContext that dials with dial and then does the TLS handshake itself,
+// so the pin check knows which host it is verifying: tls.ConnectionState.ServerName is empty
+// for IP addresses, which never go in SNI.
+func dialTLS(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		cfg := tlsConfig.Clone()
+		cfg.ServerName = host
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(host, cs)
+		}
+		tlsConn := tls.Client(conn, cfg)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
+
+// verifyPins runs after the normal chain verification and only adds the pin check
+// for hosts that have pins. Only certificates in a verified chain count: the peer can send
+// extra certificates that chain to nothing, so a pinned key among cs.PeerCertificates proves
+// nothing. With verification off (InsecureSkipVerify) there are no verified chains, so a pinned
+// host always fails.
+func verifyPins(host string, cs tls.ConnectionState) error {
+	pinMu.RLock()
+	want := pins[host]
+	pinMu.RUnlock()
+	if len(want) == 0 {
+		return nil
+	}
+
+	for _, chain := range cs.VerifiedChains {
+		for _, cert := range chain {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			if want[hex.EncodeToString(sum[:])] {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrPinMismatch, host)
+}
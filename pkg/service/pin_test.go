@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPinnedKeys(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	old := tlsConfig
+	tlsConfig = &tls.Config{RootCAs: roots}
+	t.Cleanup(func() { tlsConfig = old })
+
+	sum := sha256.Sum256(srv.Certificate().RawSubjectPublicKeyInfo)
+	match := hex.EncodeToString(sum[:])
+	other := strings.Repeat("ab", sha256.Size)
+	addr := srv.Listener.Addr().String()
+	host, _, _ := net.SplitHostPort(addr)
+	t.Cleanup(func() { SetPinnedKeys(host) })
+
+	tests := []struct {
+		name    string
+		pins    []string
+		wantErr error
+	}{
+		{"unpinned", nil, nil},
+		{"matching pin", []string{other, match}, nil},
+		{"matching pin with colons, upper case", []string{strings.ToUpper(colons(match))}, nil},
+		{"mismatched pin", []string{other}, ErrPinMismatch},
+	}
+	dial := dialTLS((&net.Dialer{}).DialContext)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetPinnedKeys(host, tt.pins...)
+			conn, err := dial(context.Background(), "tcp", addr)
+			if err == nil {
+				conn.Close()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("dial = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("pinned key outside a verified chain", func(t *testing.T) {
+		SetPinnedKeys(host, match)
+		cs := tls.ConnectionState{PeerCertificates: []*x509.Certificate{srv.Certificate()}}
+		if err := verifyPins(host, cs); !errors.Is(err, ErrPinMismatch) {
+			t.Fatalf("verifyPins = %v, want ErrPinMismatch", err)
+		}
+	})
+}
+
+// colons writes a hex fingerprint as colon-separated byte pairs, as openssl prints them.
+func colons(fp string) string {
+	var pairs []string
+	for i := 0; i < len(fp); i += 2 {
+		pairs = append(pairs, fp[i:i+2])
+	}
+	return strings.Join(pairs, ":")
+}
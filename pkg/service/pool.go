@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDNS marks a downstream call that failed because its host name couldn't be resolved within the DNS timeout.
+var ErrDNS = errors.New("dns resolution failed")
+
+// dnsTimeout bounds name resolution separately from the overall request timeout,
+// so a slow resolver fails fast instead of eating the whole budget.
+var dnsTimeout atomic.Int64
+
+func init() {
+	dnsTimeout.Store(int64(time.Second))
+}
+
+// resolver looks up downstream host names; tests swap in one that doesn't answer.
+var resolver = net.DefaultResolver
+
+// SetDNSTimeout sets how long resolving a downstream host name may take.
+func SetDNSTimeout(d time.Duration) {
+	dnsTimeout.Store(int64(d))
+}
+
+// HostPoolStats describes the outbound connections to one downstream host.
+// Created < Requests means keep-alive connections are being reused.
+type HostPoolStats struct {
+	Created  int64 `json:"created"`  // connections dialed
+	Closed   int64 `json:"closed"`   // connections closed
+	Active   int64 `json:"active"`   // open connections currently serving a request
+	Idle     int64 `json:"idle"`     // open connections parked in the pool
+	Requests int64 `json:"requests"` // requests that got a connection
+	Reused   int64 `json:"reused"`   // requests that got an already-open connection
+
+	DNSLookups  int64   `json:"dns_lookups"`
+	DNSFailures int64   `json:"dns_failures"`
+	DNSAvgMs    float64 `json:"dns_avg_ms"`
+}
+
+// poolTracker counts dials, reuse and idle connections per host for the instrumented transport.
+type poolTracker struct {
+	mu    sync.Mutex
+	hosts map[string]*hostCounters
+}
+
+type hostCounters struct {
+	created, closed, idle, requests, reused int64
+	dnsLookups, dnsFailures                 int64
+	dnsTotal                                time.Duration
+}
+
+// pool is shared by the resty client's transport; see PoolStats.
+var pool = &poolTracker{hosts: make(map[string]*hostCounters)}
+
+// PoolStats returns a snapshot of the outbound connection pool, keyed by host:port.
+func PoolStats() map[string]HostPoolStats {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	out := make(map[string]HostPoolStats, len(pool.hosts))
+	for host, h := range pool.hosts {
+		open := h.created - h.closed
+		stats := HostPoolStats{
+			Created:     h.created,
+			Closed:      h.closed,
+			Active:      open - h.idle,
+			Idle:        h.idle,
+			Requests:    h.requests,
+			Reused:      h.reused,
+			DNSLookups:  h.dnsLookups,
+			DNSFailures: h.dnsFailures,
+		}
+		if h.dnsLookups > 0 {
+			stats.DNSAvgMs = float64(h.dnsTotal.Microseconds()) / 1000 / float64(h.dnsLookups)
+		}
+		out[host] = stats
+	}
+	return out
+}
+
+// host returns the counters for addr. Caller must hold p.mu.
+func (p *poolTracker) host(addr string) *hostCounters {
+	h, ok := p.hosts[addr]
+	if !ok {
+		h = &hostCounters{}
+		p.hosts[addr] = h
+	}
+	return h
+}
+
+// trackedConn reports its own close to the tracker and remembers whether it's sitting idle in the pool.
+type trackedConn struct {
+	net.Conn
+	addr      string
+	idle      bool // guarded by pool.mu
+	closeOnce sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.closeOnce.Do(func() {
+		pool.mu.Lock()
+		h := pool.host(c.addr)
+		h.closed++
+		if c.idle {
+			h.idle--
+			c.idle = false
+		}
+		pool.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
+
+// newPoolTransport returns a clone of http.DefaultTransport whose connections are counted in pool.
+func newPoolTransport() http.RoundTripper {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := resolve(ctx, addr, host)
+		if err != nil {
+			return nil, err
+		}
+
+		// Try every resolved address in order, like net.Dialer does for a host name.
+		var conn net.Conn
+		for _, ip := range ips {
+			conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		pool.mu.Lock()
+		pool.host(addr).created++
+		pool.mu.Unlock()
+		return &trackedConn{Conn: conn, addr: addr}, nil
+	}
+	t.DialTLSContext = dialTLS(t.DialContext) // checks pinned keys, see SetPinnedKeys
+	return &poolTransport{base: t}
+}
+
+// resolve looks host up under its own DNS timeout and records the lookup against addr.
+// Failures are wrapped in ErrDNS.
+func resolve(ctx context.Context, addr, host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(dnsTimeout.Load()))
+	defer cancel()
+
+	start := time.Now()
+	ips, err := resolver.LookupHost(ctx, host)
+
+	pool.mu.Lock()
+	h := pool.host(addr)
+	h.dnsLookups++
+	h.dnsTotal += time.Since(start)
+	if err != nil {
+		h.dnsFailures++
+	}
+	pool.mu.Unlock()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrDNS, host, err)
+	}
+	return ips, nil
+}
+
+// poolTransport attaches an httptrace.ClientTrace to every request to see which connection it got
+// and whether that connection went back to the idle pool afterwards.
+type poolTransport struct {
+	base http.RoundTripper
+}
+
+func (t *poolTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var conn *trackedConn // the connection this request got, set by GotConn
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			raw := info.Conn
+			if tlsConn, ok := raw.(*tls.Conn); ok {
+				raw = tlsConn.NetConn() // dialTLS wraps the tracked conn
+			}
+			tc, ok := raw.(*trackedConn)
+			if !ok {
+				return
+			}
+			pool.mu.Lock()
+			defer pool.mu.Unlock()
+			conn = tc
+			h := pool.host(tc.addr)
+			h.requests++
+			if info.Reused {
+				h.reused++
+			}
+			if tc.idle {
+				h.idle--
+				tc.idle = false
+			}
+		},
+		PutIdleConn: func(err error) {
+			pool.mu.Lock()
+			defer pool.mu.Unlock()
+			if err != nil || conn == nil || conn.idle {
+				return
+			}
+			conn.idle = true
+			pool.host(conn.addr).idle++
+		},
+	}
+
+	return t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
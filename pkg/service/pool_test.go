@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlowDNSFailsWithinTheDNSTimeout(t *testing.T) {
+	// A name server that never answers.
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	old := resolver
+	resolver = &net.Resolver{PreferGo: true, Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "udp", silent.LocalAddr().String())
+	}}
+	SetDNSTimeout(200 * time.Millisecond)
+	t.Cleanup(func() {
+		resolver = old
+		SetDNSTimeout(time.Second)
+	})
+
+	client := &http.Client{Transport: newPoolTransport(), Timeout: 10 * time.Second}
+	start := time.Now()
+	_, err = client.Get("http://slow-dns.test/users/1")
+	elapsed := time.Since(start)
+
+	if got := Category(err); got != "dns" {
+		t.Fatalf("Category(%v) = %q, want %q", err, got, "dns")
+	}
+	if elapsed < 200*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("failed after %s, want at the 200ms DNS timeout", elapsed)
+	}
+	if stats := PoolStats()["slow-dns.test:80"]; stats.DNSFailures == 0 {
+		t.Fatalf("pool stats = %+v, want the failed lookup counted", stats)
+	}
+}
+
+func TestPoolStatsCountReusedAndIdleConnections(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	transport := newPoolTransport()
+	client := &http.Client{Transport: transport}
+
+	for range 3 {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+
+	// The connection goes back to the pool from the transport's own goroutine.
+	stats := waitForPoolStats(t, addr, func(s HostPoolStats) bool { return s.Idle == 1 })
+	want := HostPoolStats{Created: 1, Idle: 1, Requests: 3, Reused: 2, DNSLookups: 1, DNSAvgMs: stats.DNSAvgMs}
+	if stats != want {
+		t.Fatalf("after 3 requests PoolStats = %+v, want %+v", stats, want)
+	}
+
+	transport.(*poolTransport).base.(*http.Transport).CloseIdleConnections()
+	stats = waitForPoolStats(t, addr, func(s HostPoolStats) bool { return s.Closed == 1 })
+	if stats.Idle != 0 || stats.Active != 0 {
+		t.Fatalf("after closing idle connections PoolStats = %+v, want none open", stats)
+	}
+}
+
+// waitForPoolStats polls PoolStats for addr until ok accepts them, for up to a second.
+func waitForPoolStats(t *testing.T, addr string, ok func(HostPoolStats) bool) HostPoolStats {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		stats := PoolStats()[addr]
+		if ok(stats) || time.Now().After(deadline) {
+			return stats
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// probeClient sends readiness probes. It shares the fetchers' transport, so pinned keys and
+// the connection pool apply, but none of their retries: a probe is a single attempt.
+var probeClient = &http.Client{Transport: transport}
+
+// Probe checks that the named service is reachable with a HEAD to its base URL, and returns
+// how long it took. Any answer below 500 counts as reachable, since the base URL needn't be a
+// route the service serves. In offline mode the service is up if it has a fake (see RegisterFake).
+// The probe gives up after timeout, independently of any fetch budget.
+func Probe(ctx context.Context, name string, timeout time.Duration) (time.Duration, error) {
+	start := time.Now()
+	if fake, ok := offlineFetcher(name); ok {
+		_, err := fake("probe")
+		return time.Since(start), err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, fetchConfig(name).url("/"), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := probeClient.Do(req)
+	if err != nil {
+		return time.Since(start), err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return time.Since(start), fmt.Errorf("%w: %s", ErrBadStatus, resp.Status)
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fetcher fetches one downstream service's data for a user.
+type Fetcher func(ctx context.Context, userID string) (any, error)
+
+// Registry maps service names to their fetchers. It is the single list of services
+// the aggregate handlers fan out to.
+//
+// A service may also have a Writer, for the aggregate write endpoint.
+//
+// Register is expected at startup; Get, All and Names are safe to call concurrently
+// from request handlers at any time.
+type Registry struct {
+	mu       sync.RWMutex
+	fetchers map[string]Fetcher
+	writers  map[string]Writer
+}
+
+// Default is the registry the handlers read from. It starts with every service cmd/mock-service provides,
+// each behind ResponseCache, request coalescing (see Coalesce), its own circuit breaker
+// (5 consecutive failures opens it for 30s; once it closes again traffic ramps back up over 10s)
+// and the timeout escalation set for it, if any (see SetTimeoutEscalation). The breakers are
+// reachable through BreakerFor, and can be shared by host instead (see SetBreakerGrouping).
+// While a service's breaker is open, its fallback service answers for it, if it has one (see
+// SetFallbackService).
+// Only user is cached by default: its data is effectively static.
+// Each service also has a writer POSTing to the same path, which updates ResponseCache as it goes
+// (see Cache.WrapWriter).
+var Default = NewRegistry()
+
+func init() {
+	ResponseCache.SetTTL("user", 30*time.Second)
+
+	for name, path := range map[string]string{
+		"user":          "/mock/user/",
+		"orders":        "/mock/orders/",
+		"notifications": "/mock/notifications/",
+		"inventory":     "/mock/inventory/",
+	} {
+		RegisterBreaker(name, newDefaultBreaker())
+		Default.Register(name, viaFallbackService(name, ResponseCache.Wrap(name, Coalesce(WrapBreaker(name, escalated(name, HTTPFetcher(name, path)))))))
+		Default.RegisterWriter(name, ResponseCache.WrapWriter(name, HTTPWriter(name, path)))
+	}
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{fetchers: make(map[string]Fetcher), writers: make(map[string]Writer)}
+}
+
+// Register adds fn under name. Registering a name that already exists replaces
+// the previous fetcher (last registration wins), which is how a default is overridden.
+func (r *Registry) Register(name string, fn Fetcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetchers[name] = fn
+}
+
+// RegisterWriter sets the writer for name, replacing any previous one.
+func (r *Registry) RegisterWriter(name string, fn Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writers[name] = fn
+}
+
+// GetWriter returns the writer registered under name.
+func (r *Registry) GetWriter(name string) (Writer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.writers[name]
+	return fn, ok
+}
+
+// Get returns the fetcher registered under name.
+func (r *Registry) Get(name string) (Fetcher, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	fn, ok := r.fetchers[name]
+	return fn, ok
+}
+
+// All returns a copy of every registered fetcher keyed by name.
+// Map iteration order is random; use Names when order matters.
+func (r *Registry) All() map[string]Fetcher {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[string]Fetcher, len(r.fetchers))
+	for name, fn := range r.fetchers {
+		out[name] = fn
+	}
+	return out
+}
+
+// Names returns every registered service name in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.fetchers))
+	for name := range r.fetchers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HTTPFetcher returns a Fetcher that GETs path+userID from name's base URL using name's FetchConfig.
+// It is the plain fetcher behind every default service, for building a service with another chain of
+// wrappers. Registering it as is drops the default's cache, coalescing and breaker, so keep those, e.g.
+//
+//	Default.Register("reviews", ResponseCache.Wrap("reviews", Coalesce(NewBreaker(5, 30*time.Second).Wrap(HTTPFetcher("reviews", "/mock/reviews/")))))
+//
+// A default service's timeout escalation is set with SetTimeoutEscalation instead.
+func HTTPFetcher(name, path string) Fetcher {
+	return func(ctx context.Context, userID string) (any, error) {
+		cfg := fetchConfig(name)
+		return get(ctx, name, userID, cfg.url(path+userID), cfg)
+	}
+}
+
+// HTTPWriter returns a Writer that POSTs the body as JSON to path+userID at name's base URL using name's
+// FetchConfig. Writes are never retried.
+func HTTPWriter(name, path string) Writer {
+	return func(ctx context.Context, userID string, body any) (any, error) {
+		cfg := fetchConfig(name)
+		return send(ctx, name, userID, http.MethodPost, cfg.url(path+userID), body, cfg)
+	}
+}
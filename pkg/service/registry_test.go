@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// answer returns a Fetcher that answers with v.
+func answer(v string) Fetcher {
+	return func(context.Context, string) (any, error) { return v, nil }
+}
+
+func TestRegistryLastRegistrationWins(t *testing.T) {
+	r := NewRegistry()
+	r.Register("orders", answer("first"))
+	r.Register("orders", answer("second"))
+
+	fn, ok := r.Get("orders")
+	if !ok {
+		t.Fatal("Get(orders) found nothing")
+	}
+	if got, _ := fn(context.Background(), "u1"); got != "second" {
+		t.Fatalf("orders answered %v, want the second registration", got)
+	}
+	if names := r.Names(); len(names) != 1 {
+		t.Fatalf("Names() = %v, want orders once", names)
+	}
+	if _, ok := r.Get("missing"); ok {
+		t.Fatal("Get(missing) found a fetcher")
+	}
+}
+
+func TestRegistryNamesAreSorted(t *testing.T) {
+	r := NewRegistry()
+	for _, name := range []string{"orders", "user", "inventory", "notifications"} {
+		r.Register(name, answer(name))
+	}
+	want := "[inventory notifications orders user]"
+	for range 10 {
+		if got := fmt.Sprint(r.Names()); got != want {
+			t.Fatalf("Names() = %s, want %s", got, want)
+		}
+	}
+	if all := r.All(); len(all) != 4 {
+		t.Fatalf("All() has %d fetchers, want 4", len(all))
+	}
+}
+
+// TestRegistryConcurrentUse reads while registering; run it with -race.
+func TestRegistryConcurrentUse(t *testing.T) {
+	r := NewRegistry()
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.Register(fmt.Sprintf("svc-%d", i), answer("x"))
+		}()
+		go func() {
+			defer wg.Done()
+			r.Names()
+			r.All()
+			r.Get("svc-0")
+		}()
+	}
+	wg.Wait()
+	if got := len(r.Names()); got != 8 {
+		t.Fatalf("%d services registered, want 8", got)
+	}
+}
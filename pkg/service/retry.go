@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// retryPolicy is how the clients retry a failed attempt.
+type retryPolicy struct {
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxRetries int
+}
+
+// retry is the policy every client is built with. Guarded by clientsMu.
+var retry = retryPolicy{baseDelay: 100 * time.Millisecond, maxDelay: 2 * time.Second, maxRetries: 2}
+
+// SetRetryPolicy sets how failed downstream attempts are retried: up to maxRetries more
+// attempts, waiting an exponentially growing, jittered delay between them that starts
+// around baseDelay and never exceeds maxDelay. Defaults are 100ms, 2s and 2 retries.
+//
+// Only connection errors (including per-attempt timeouts) and 5xx responses are retried,
+// never 4xx. No attempt starts, and no wait continues, once the call's context is done, and no
+// retry is made when the time left before its deadline is less than the service's median latency
+// over the last 5 minutes (see Stats): it would most likely be cut off anyway.
+//
+// The clients are rebuilt rather than reconfigured, so calls already in flight keep the
+// policy they started with.
+func SetRetryPolicy(baseDelay, maxDelay time.Duration, maxRetries int) {
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	retry = retryPolicy{baseDelay: baseDelay, maxDelay: maxDelay, maxRetries: maxRetries}
+	client = newClient(client.GetClient().Timeout)
+	for size, c := range payloadClients {
+		payloadClients[size] = newClient(c.GetClient().Timeout)
+	}
+	for d := range attemptClients {
+		attemptClients[d] = newClient(d)
+	}
+}
+
+// retryable reports whether an attempt should be retried: it failed to get a response at all, or got a 5xx,
+// and there is enough time left for another attempt (see worthRetrying).
+// Only reads are retried; repeating a write that may already have been applied isn't safe.
+func retryable(resp *resty.Response, err error) bool {
+	if resp != nil && resp.Request != nil {
+		if resp.Request.Method != http.MethodGet || !worthRetrying(resp.Request.Context()) {
+			return false
+		}
+	}
+	if err != nil {
+		return true
+	}
+	// A 508 is a gateway loop (see HopsHeader): retrying only sends the request round again.
+	return resp != nil && resp.StatusCode() >= 500 && resp.StatusCode() != http.StatusLoopDetected
+}
+
+// retryBudgetWindow is how far back worthRetrying looks at a service's latency.
+const retryBudgetWindow = 5 * time.Minute
+
+type callServiceKey struct{}
+
+// withCallService returns a copy of ctx for a downstream call to the named service.
+func withCallService(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, callServiceKey{}, name)
+}
+
+// worthRetrying reports whether a call under ctx has at least its service's median latency left
+// before its deadline, so a retry could plausibly finish. A call without a deadline, or to a
+// service with no recent latency samples, always has.
+func worthRetrying(ctx context.Context) bool {
+	deadline, ok := ctx.Deadline()
+	name, _ := ctx.Value(callServiceKey{}).(string)
+	if !ok || name == "" {
+		return true
+	}
+	p50, ok := Stats.Percentile(name, retryBudgetWindow, 0.5)
+	return !ok || time.Until(deadline) >= p50
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetriesSkippedWhenLittleBudgetRemains(t *testing.T) {
+	SetRetryPolicy(time.Millisecond, time.Millisecond, 2)
+	t.Cleanup(func() { SetRetryPolicy(100*time.Millisecond, 2*time.Second, 2) })
+
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	for range 10 {
+		Stats.Record("budget-slow", time.Second, nil)
+		Stats.Record("budget-fast", time.Millisecond, nil)
+	}
+	tests := []struct {
+		service  string
+		attempts int32
+	}{
+		{"budget-slow", 1}, // a median of 1s can't fit in the 200ms left
+		{"budget-fast", 3},
+		{"budget-unseen", 3}, // no samples to judge by
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			hits.Store(0)
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			if _, err := (restyDoer{client: clientFor(""), name: tt.service}).Do(ctx, http.MethodGet, srv.URL, nil); err == nil {
+				t.Fatal("Do() succeeded against a failing server")
+			}
+			if got := hits.Load(); got != tt.attempts {
+				t.Fatalf("%d attempts, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestOverallAndAttemptTimeoutsAreToldApart(t *testing.T) {
+	SetRetryPolicy(time.Millisecond, time.Millisecond, 2)
+	t.Cleanup(func() { SetRetryPolicy(100*time.Millisecond, 2*time.Second, 2) })
+
+	// Every attempt takes delay and then fails, so the call keeps retrying.
+	slow := func(delay time.Duration) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	}
+
+	t.Run("overall", func(t *testing.T) {
+		srv := slow(30 * time.Millisecond)
+		defer srv.Close()
+
+		// Each 30ms attempt fits in its 500ms, but three of them don't fit in 50ms.
+		cfg := FetchConfig{Timeout: 50 * time.Millisecond, AttemptTimeout: 500 * time.Millisecond}
+		_, err := get(context.Background(), "timeouts-overall", "u1", srv.URL, cfg)
+		if !errors.Is(err, ErrBudgetExhausted) || errors.Is(err, ErrAttemptTimeout) {
+			t.Fatalf("err = %v, want ErrBudgetExhausted", err)
+		}
+		if got := Category(err); got != "timeout" {
+			t.Fatalf("Category() = %q, want timeout", got)
+		}
+	})
+
+	t.Run("attempt", func(t *testing.T) {
+		srv := slow(time.Second)
+		defer srv.Close()
+
+		cfg := FetchConfig{Timeout: 5 * time.Second, AttemptTimeout: 20 * time.Millisecond}
+		_, err := get(context.Background(), "timeouts-attempt", "u1", srv.URL, cfg)
+		if !errors.Is(err, ErrAttemptTimeout) || errors.Is(err, ErrBudgetExhausted) {
+			t.Fatalf("err = %v, want ErrAttemptTimeout", err)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("err = %v, want it to match context.DeadlineExceeded", err)
+		}
+		if got := Category(err); got != "attempt_timeout" {
+			t.Fatalf("Category() = %q, want attempt_timeout", got)
+		}
+	})
+}
+
+// fakeTransport answers every attempt with status, recording when each one arrived.
+type fakeTransport struct {
+	mu        sync.Mutex
+	status    int
+	attempts  []time.Time
+	onAttempt func(n int) // called after the nth attempt (1-based), if set
+}
+
+func (f *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.attempts = append(f.attempts, time.Now())
+	n := len(f.attempts)
+	f.mu.Unlock()
+	if f.onAttempt != nil {
+		f.onAttempt(n)
+	}
+	return &http.Response{
+		StatusCode: f.status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+		Request:    req,
+	}, nil
+}
+
+func (f *fakeTransport) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.attempts)
+}
+
+func TestRetriesBackOffExponentially(t *testing.T) {
+	const base = 20 * time.Millisecond
+	SetRetryPolicy(base, time.Second, 4)
+	t.Cleanup(func() { SetRetryPolicy(100*time.Millisecond, 2*time.Second, 2) })
+
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+	}{
+		{"5xx is retried", http.StatusServiceUnavailable, 5},
+		{"4xx is not", http.StatusNotFound, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeTransport{status: tt.status}
+			d := restyDoer{client: newClient(time.Second).SetTransport(fake), name: "backoff"}
+			if _, err := d.Do(context.Background(), http.MethodGet, "http://backoff.test/", nil); !errors.Is(err, ErrBadStatus) {
+				t.Fatalf("Do() = %v, want ErrBadStatus", err)
+			}
+			if got := fake.count(); got != tt.attempts {
+				t.Fatalf("%d attempts, want %d", got, tt.attempts)
+			}
+
+			// Resty waits at least base, then a jittered [base*2^(n-2), base*2^(n-1)) before the nth
+			// retry, so the waits keep growing: the last is several times the first.
+			waits := make([]time.Duration, 0, len(fake.attempts))
+			for i := 1; i < len(fake.attempts); i++ {
+				waits = append(waits, fake.attempts[i].Sub(fake.attempts[i-1]))
+			}
+			for n, wait := range waits {
+				floor := base
+				if n > 0 {
+					floor = base << (n - 1)
+				}
+				if wait < floor {
+					t.Fatalf("waits = %v: wait %d is under %v", waits, n+1, floor)
+				}
+			}
+			if len(waits) > 0 && waits[len(waits)-1] < 3*waits[0] {
+				t.Fatalf("waits = %v, want them to grow", waits)
+			}
+		})
+	}
+}
+
+func TestRetriesStopWhenTheContextIsCancelled(t *testing.T) {
+	SetRetryPolicy(50*time.Millisecond, time.Second, 5)
+	t.Cleanup(func() { SetRetryPolicy(100*time.Millisecond, 2*time.Second, 2) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := &fakeTransport{status: http.StatusServiceUnavailable}
+	fake.onAttempt = func(n int) {
+		if n == 2 {
+			time.AfterFunc(10*time.Millisecond, cancel) // while waiting to retry a second time
+		}
+	}
+	d := restyDoer{client: newClient(time.Second).SetTransport(fake), name: "backoff-cancel"}
+	if _, err := d.Do(ctx, http.MethodGet, "http://backoff.test/", nil); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do() = %v, want context.Canceled", err)
+	}
+	time.Sleep(200 * time.Millisecond) // longer than any wait left
+	if got := fake.count(); got != 2 {
+		t.Fatalf("%d attempts, want 2: none after the cancel", got)
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"sync"
+)
+
+// RetryLog collects how many retries each service's fetch needed during one request.
+type RetryLog struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+type retryLogKey struct{}
+
+// WithRetryLog returns a copy of ctx that records into a new RetryLog the retries of every
+// fetch made with it.
+func WithRetryLog(ctx context.Context) (context.Context, *RetryLog) {
+	log := &RetryLog{counts: make(map[string]int)}
+	return context.WithValue(ctx, retryLogKey{}, log), log
+}
+
+// Counts returns the retries recorded so far by service name. Services that were
+// served without a downstream call (cache, fake, paused) are absent.
+func (l *RetryLog) Counts() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]int, len(l.counts))
+	for name, n := range l.counts {
+		out[name] = n
+	}
+	return out
+}
+
+// recordRetries adds retries for name to ctx's RetryLog, if it has one.
+func recordRetries(ctx context.Context, name string, retries int) {
+	log, ok := ctx.Value(retryLogKey{}).(*RetryLog)
+	if !ok {
+		return
+	}
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.counts[name] += retries
+}
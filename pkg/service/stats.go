@@ -0,0 +1,158 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxSamplesPerBucket bounds how many latency samples a single service keeps per bucket,
+// so a traffic spike can't grow a bucket without limit.
+const maxSamplesPerBucket = 1024
+
+// Stats is the package-level latency recorder every fetcher reports into.
+// 60 one-minute buckets = the last hour of history.
+var Stats = NewLatencyStats(time.Minute, 60)
+
+// latencyBucket holds the samples of every service that were recorded during [start, start+width).
+// calls and errors are counted separately from samples because samples are capped.
+type latencyBucket struct {
+	start   time.Time
+	samples map[string][]time.Duration
+	calls   map[string]int
+	errors  map[string]int
+}
+
+// LatencyStats keeps per-service latency samples in a ring buffer of fixed-width time buckets.
+// Old buckets are overwritten in place as time moves on, so memory stays bounded.
+type LatencyStats struct {
+	mu      sync.Mutex
+	width   time.Duration
+	buckets []latencyBucket
+	now     func() time.Time
+}
+
+// SlowestService is the service with the highest p95 latency inside one bucket.
+type SlowestService struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Service     string    `json:"service"`
+	P95Ms       float64   `json:"p95_ms"`
+	Samples     int       `json:"samples"`
+}
+
+// NewLatencyStats returns a ring of count buckets, each covering width of time.
+func NewLatencyStats(width time.Duration, count int) *LatencyStats {
+	return &LatencyStats{
+		width:   width,
+		buckets: make([]latencyBucket, count),
+		now:     time.Now,
+	}
+}
+
+// Record stores the outcome of one call to the given service in the current bucket.
+func (s *LatencyStats) Record(service string, d time.Duration, err error) {
+	s.RecordAt(service, d, err, s.now())
+}
+
+// RecordAt stores a call outcome as if it had been observed at the given time.
+func (s *LatencyStats) RecordAt(service string, d time.Duration, err error, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := s.bucketFor(at)
+	if b == nil {
+		return
+	}
+	b.calls[service]++
+	if err != nil {
+		b.errors[service]++
+	}
+	if len(b.samples[service]) < maxSamplesPerBucket {
+		b.samples[service] = append(b.samples[service], d)
+	}
+}
+
+// bucketFor returns the ring slot for the given time, resetting it if it still holds an older bucket.
+// It returns nil when the slot has already been reused by a newer bucket (the sample is too old to keep).
+// Caller must hold s.mu.
+func (s *LatencyStats) bucketFor(at time.Time) *latencyBucket {
+	start := at.Truncate(s.width)
+	idx := int((start.UnixNano() / int64(s.width)) % int64(len(s.buckets)))
+	b := &s.buckets[idx]
+	if b.samples != nil && b.start.After(start) {
+		return nil
+	}
+	if !b.start.Equal(start) || b.samples == nil {
+		b.start = start
+		b.samples = make(map[string][]time.Duration)
+		b.calls = make(map[string]int)
+		b.errors = make(map[string]int)
+	}
+	return b
+}
+
+// Slowest returns, oldest first, the service with the highest p95 latency for every
+// bucket that falls inside the last window and has at least one sample.
+func (s *LatencyStats) Slowest(window time.Duration) []SlowestService {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-window).Truncate(s.width)
+	out := make([]SlowestService, 0)
+	for _, b := range s.buckets {
+		if b.samples == nil || b.start.Before(cutoff) {
+			continue
+		}
+
+		var slowest *SlowestService
+		for name, samples := range b.samples {
+			p95 := percentile(samples, 0.95)
+			// Break ties on name so the answer doesn't depend on map iteration order.
+			if slowest == nil || p95 > slowest.P95Ms || (p95 == slowest.P95Ms && name < slowest.Service) {
+				slowest = &SlowestService{BucketStart: b.start, Service: name, P95Ms: p95, Samples: len(samples)}
+			}
+		}
+		if slowest != nil {
+			out = append(out, *slowest)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].BucketStart.Before(out[j].BucketStart) })
+	return out
+}
+
+// Percentile returns the p-th percentile (0 < p <= 1) of the named service's latency over the
+// buckets inside the last window. ok is false if it has no samples there.
+func (s *LatencyStats) Percentile(service string, window time.Duration, p float64) (d time.Duration, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := s.now().Add(-window).Truncate(s.width)
+	var samples []time.Duration
+	for _, b := range s.buckets {
+		if b.samples != nil && !b.start.Before(cutoff) {
+			samples = append(samples, b.samples[service]...)
+		}
+	}
+	if len(samples) == 0 {
+		return 0, false
+	}
+	return time.Duration(percentile(samples, p) * float64(time.Millisecond)), true
+}
+
+// percentile returns the p-th percentile (0 < p <= 1) of samples in milliseconds using the nearest-rank method.
+func percentile(samples []time.Duration, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return float64(sorted[rank]) / float64(time.Millisecond)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSlowestPicksTheHighestP95PerBucket(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC)
+	s := NewLatencyStats(time.Minute, 60)
+	s.now = func() time.Time { return now }
+
+	// Two buckets ago orders was slowest; in the current one user is, failed calls included.
+	earlier := now.Add(-2 * time.Minute)
+	for range 10 {
+		s.RecordAt("orders", 300*time.Millisecond, nil, earlier)
+		s.RecordAt("user", 20*time.Millisecond, nil, earlier)
+		s.RecordAt("orders", 40*time.Millisecond, nil, now)
+		s.RecordAt("user", 500*time.Millisecond, errors.New("boom"), now)
+	}
+	// Outside the window asked for below.
+	s.RecordAt("inventory", time.Second, nil, now.Add(-30*time.Minute))
+
+	got := s.Slowest(5 * time.Minute)
+	want := []SlowestService{
+		{BucketStart: earlier.Truncate(time.Minute), Service: "orders", P95Ms: 300, Samples: 10},
+		{BucketStart: now.Truncate(time.Minute), Service: "user", P95Ms: 500, Samples: 10},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Slowest = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Slowest[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestSlowestBreaksTiesOnName(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC)
+	s := NewLatencyStats(time.Minute, 60)
+	s.now = func() time.Time { return now }
+	s.RecordAt("orders", 100*time.Millisecond, nil, now)
+	s.RecordAt("inventory", 100*time.Millisecond, nil, now)
+
+	got := s.Slowest(time.Minute)
+	if len(got) != 1 || got[0].Service != "inventory" {
+		t.Fatalf("Slowest = %+v, want inventory", got)
+	}
+}
+
+func TestSlowestWithNoSamplesIsEmpty(t *testing.T) {
+	s := NewLatencyStats(time.Minute, 60)
+	if got := s.Slowest(time.Hour); got == nil || len(got) != 0 {
+		t.Fatalf("Slowest = %#v, want an empty slice", got)
+	}
+}
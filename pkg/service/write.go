@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrReadOnly is returned for a write to a service that can't take one right now: it is paused
+// for maintenance (see Pause) or the gateway is in offline mode (see SetOffline).
+var ErrReadOnly = errors.New("service is read-only")
+
+// Writer sends a write for a user to one downstream service, with body as the JSON request
+// body, and returns the service's response: the user's data as it is after the write.
+type Writer func(ctx context.Context, userID string, body any) (any, error)
+
+// WritePolicy is what a successful write does to the service's cached response for the user.
+type WritePolicy string
+
+const (
+	WriteInvalidate WritePolicy = "invalidate"    // drop the cached response; the next read goes downstream
+	WriteThrough    WritePolicy = "write-through" // cache the write's response as the user's data
+)
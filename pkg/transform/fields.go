@@ -0,0 +1,93 @@
+package transform
+
+import "strings"
+
+// SelectFields returns a copy of data holding only the dotted paths asked for, e.g.
+// "user.name" or "orders.orders.id". A path segment that reaches an array applies the rest
+// of the path to every element. Paths that don't exist in data are left out of the result
+// and returned in skipped. data itself is never modified.
+func SelectFields(data map[string]any, paths []string) (selected map[string]any, skipped []string) {
+	selected = make(map[string]any)
+	for _, path := range paths {
+		segs := strings.Split(path, ".")
+		v, ok := selectPath(data, segs)
+		if !ok {
+			skipped = append(skipped, path)
+			continue
+		}
+		selected = merge(selected, v).(map[string]any)
+	}
+	return selected, skipped
+}
+
+// selectPath returns the part of v reached by segs, wrapped in the same structure it was found in.
+func selectPath(v any, segs []string) (any, bool) {
+	if len(segs) == 0 {
+		return v, true
+	}
+
+	switch t := v.(type) {
+	case map[string]any:
+		child, ok := t[segs[0]]
+		if !ok || segs[0] == "" {
+			return nil, false
+		}
+		sub, ok := selectPath(child, segs[1:])
+		if !ok {
+			return nil, false
+		}
+		return map[string]any{segs[0]: sub}, true
+	case []any:
+		// Keep every element so positions still line up; the path only has to exist in one.
+		out := make([]any, len(t))
+		found := false
+		for i, item := range t {
+			sub, ok := selectPath(item, segs)
+			if ok {
+				out[i] = sub
+				found = true
+			} else {
+				out[i] = map[string]any{}
+			}
+		}
+		return out, found
+	default:
+		return nil, false
+	}
+}
+
+// merge combines two selections of the same data: objects are merged key by key and
+// arrays element by element. Neither argument is modified.
+func merge(a, b any) any {
+	switch at := a.(type) {
+	case map[string]any:
+		bt, ok := b.(map[string]any)
+		if !ok {
+			return b
+		}
+		out := make(map[string]any, len(at)+len(bt))
+		for k, v := range at {
+			out[k] = v
+		}
+		for k, v := range bt {
+			if existing, ok := out[k]; ok {
+				out[k] = merge(existing, v)
+			} else {
+				out[k] = v
+			}
+		}
+		return out
+	case []any:
+		bt, ok := b.([]any)
+		if !ok || len(bt) != len(at) {
+			return b
+		}
+		out := make([]any, len(at))
+		for i := range at {
+			out[i] = merge(at[i], bt[i])
+		}
+		return out
+	default:
+		return b
+	}
+}
@@ -0,0 +1,53 @@
+package transform
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectFields(t *testing.T) {
+	data := func() map[string]any {
+		return map[string]any{
+			"user": map[string]any{"id": "1", "name": "Ada", "address": map[string]any{"city": "London", "zip": "N1"}},
+			"orders": map[string]any{"orders": []any{
+				map[string]any{"id": "o1", "total": 10.0},
+				map[string]any{"id": "o2", "total": 20.0, "coupon": "SAVE"},
+			}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		paths       []string
+		want        map[string]any
+		wantSkipped []string
+	}{
+		{"a.b", []string{"user.name"},
+			map[string]any{"user": map[string]any{"name": "Ada"}}, nil},
+		{"deeper and merged", []string{"user.address.city", "user.id"},
+			map[string]any{"user": map[string]any{"id": "1", "address": map[string]any{"city": "London"}}}, nil},
+		{"array elements", []string{"orders.orders.id"},
+			map[string]any{"orders": map[string]any{"orders": []any{map[string]any{"id": "o1"}, map[string]any{"id": "o2"}}}}, nil},
+		{"field in some elements only", []string{"orders.orders.coupon"},
+			map[string]any{"orders": map[string]any{"orders": []any{map[string]any{}, map[string]any{"coupon": "SAVE"}}}}, nil},
+		{"nonexistent path", []string{"user.email", "reviews", "user.name.first", "user."},
+			map[string]any{}, []string{"user.email", "reviews", "user.name.first", "user."}},
+		{"existing and nonexistent", []string{"user.id", "user.email"},
+			map[string]any{"user": map[string]any{"id": "1"}}, []string{"user.email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := data()
+			got, skipped := SelectFields(in, tt.paths)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("SelectFields(%v) = %v, want %v", tt.paths, got, tt.want)
+			}
+			if !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Fatalf("skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+			if !reflect.DeepEqual(in, data()) {
+				t.Fatal("SelectFields modified its input")
+			}
+		})
+	}
+}
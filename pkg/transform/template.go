@@ -0,0 +1,66 @@
+package transform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+)
+
+// ErrTemplate is returned when a response template fails to run or doesn't produce JSON.
+var ErrTemplate = errors.New("response template failed")
+
+// Template reshapes one service's response declaratively: a text/template run on the decoded
+// response whose output is parsed back as JSON. The json function renders a value as JSON,
+// which is how strings get quoted, e.g. to rename fields:
+//
+//	{"fullName": {{json .name}}, "contact": {"email": {{json .email}}}}
+//
+// A field the response lacks renders as null rather than failing.
+type Template struct {
+	tmpl *template.Template
+}
+
+// ParseTemplate parses text as the response template for the named service.
+func ParseTemplate(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=zero").
+		Funcs(template.FuncMap{"json": toJSON}).
+		Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// Apply runs the template on data and returns its output decoded as JSON. data is only read.
+func (t *Template) Apply(data any) (any, error) {
+	var out bytes.Buffer
+	if err := t.tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTemplate, err)
+	}
+	var reshaped any
+	if err := json.Unmarshal(out.Bytes(), &reshaped); err != nil {
+		return nil, fmt.Errorf("%w: output is not JSON: %v", ErrTemplate, err)
+	}
+	return reshaped, nil
+}
+
+// Wrap returns a fetcher (a service.Fetcher) whose successful responses are reshaped by the template.
+func (t *Template) Wrap(fetcher func(ctx context.Context, userID string) (any, error)) func(ctx context.Context, userID string) (any, error) {
+	return func(ctx context.Context, userID string) (any, error) {
+		data, err := fetcher(ctx, userID)
+		if err != nil {
+			return data, err
+		}
+		return t.Apply(data)
+	}
+}
+
+// toJSON renders v as JSON for use inside a template.
+func toJSON(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
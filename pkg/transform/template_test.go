@@ -0,0 +1,67 @@
+package transform
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTemplateRenamesFields(t *testing.T) {
+	tmpl, err := ParseTemplate("user", `{"fullName": {{json .name}}, "contact": {"email": {{json .email}}}, "phone": {{json .phone}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]any{"name": "Jane Doe", "email": "jane@example.com", "id": "7"}
+
+	got, err := tmpl.Apply(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"fullName": "Jane Doe",
+		"contact":  map[string]any{"email": "jane@example.com"},
+		"phone":    nil, // missing from the response
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Apply = %v, want %v", got, want)
+	}
+	if len(data) != 3 || data["name"] != "Jane Doe" {
+		t.Fatalf("Apply changed its input to %v", data)
+	}
+}
+
+func TestTemplateOutputMustBeJSON(t *testing.T) {
+	tmpl, err := ParseTemplate("user", `name: {{.name}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmpl.Apply(map[string]any{"name": "Jane"}); !errors.Is(err, ErrTemplate) {
+		t.Fatalf("Apply = %v, want %v", err, ErrTemplate)
+	}
+	if _, err := ParseTemplate("user", `{{.name`); err == nil {
+		t.Fatal("ParseTemplate accepted an unterminated action")
+	}
+}
+
+func TestTemplateWrapLeavesFailuresAlone(t *testing.T) {
+	tmpl, err := ParseTemplate("user", `{"who": {{json .name}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boom := errors.New("boom")
+	fetch := tmpl.Wrap(func(ctx context.Context, userID string) (any, error) {
+		if userID == "bad" {
+			return nil, boom
+		}
+		return map[string]any{"name": userID}, nil
+	})
+
+	got, err := fetch(context.Background(), "jane")
+	if err != nil || !reflect.DeepEqual(got, map[string]any{"who": "jane"}) {
+		t.Fatalf("fetch(jane) = %v, %v, want {who: jane}", got, err)
+	}
+	if _, err := fetch(context.Background(), "bad"); err != boom {
+		t.Fatalf("fetch(bad) error = %v, want %v", err, boom)
+	}
+}
@@ -0,0 +1,156 @@
+// Package transform reshapes aggregated service data before it is returned.
+package transform
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownTransform is returned by Pipeline for a name that was never registered.
+var ErrUnknownTransform = errors.New("unknown transform")
+
+// Func transforms aggregated data, keyed by service name, into new data. It must not
+// modify data or anything inside it: service data may be shared with the response cache.
+type Func func(data map[string]any) (map[string]any, error)
+
+var (
+	mu         sync.RWMutex
+	transforms = map[string]Func{
+		"filter":  Filter,
+		"flatten": Flatten,
+		"compact": Compact,
+		"reduce":  Reduce,
+	}
+)
+
+// Register adds fn under name, replacing any transform already registered there.
+func Register(name string, fn Func) {
+	mu.Lock()
+	defer mu.Unlock()
+	transforms[name] = fn
+}
+
+// Names returns every registered transform name in sorted order.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Pipeline chains the named transforms in order, each getting the previous one's output.
+// All names are resolved up front, so an unknown one fails before any data is touched.
+func Pipeline(names ...string) (Func, error) {
+	mu.RLock()
+	stages := make([]Func, 0, len(names))
+	for _, name := range names {
+		fn, ok := transforms[name]
+		if !ok {
+			mu.RUnlock()
+			return nil, fmt.Errorf("%w: %q", ErrUnknownTransform, name)
+		}
+		stages = append(stages, fn)
+	}
+	mu.RUnlock()
+
+	return func(data map[string]any) (map[string]any, error) {
+		for i, stage := range stages {
+			var err error
+			if data, err = stage(data); err != nil {
+				return nil, fmt.Errorf("transform %q: %w", names[i], err)
+			}
+		}
+		return data, nil
+	}, nil
+}
+
+// Filter drops services without usable data: null, an empty object, or a maintenance marker
+// (an object with "maintenance": true), so later stages only see real answers.
+func Filter(data map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(data))
+	for name, v := range data {
+		if v == nil {
+			continue
+		}
+		if obj, ok := v.(map[string]any); ok && (len(obj) == 0 || obj["maintenance"] == true) {
+			continue
+		}
+		out[name] = v
+	}
+	return out, nil
+}
+
+// Flatten lifts each service's fields to the top level as "service.field".
+// Service data that isn't an object is kept as is under the service name.
+func Flatten(data map[string]any) (map[string]any, error) {
+	out := make(map[string]any)
+	for name, v := range data {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			out[name] = v
+			continue
+		}
+		for k, field := range obj {
+			out[name+"."+k] = field
+		}
+	}
+	return out, nil
+}
+
+// Compact drops null fields from each service's data, and services with no data left.
+func Compact(data map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(data))
+	for name, v := range data {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			if v != nil {
+				out[name] = v
+			}
+			continue
+		}
+
+		kept := make(map[string]any, len(obj))
+		for k, field := range obj {
+			if field != nil {
+				kept[k] = field
+			}
+		}
+		if len(kept) > 0 {
+			out[name] = kept
+		}
+	}
+	return out, nil
+}
+
+// Reduce merges every service's fields into one object. Where services share a field, the first
+// service in sorted order keeps it. Service data that isn't an object is kept under the service name.
+func Reduce(data map[string]any) (map[string]any, error) {
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := make(map[string]any)
+	for _, name := range names {
+		obj, ok := data[name].(map[string]any)
+		if !ok {
+			if _, taken := out[name]; !taken {
+				out[name] = data[name]
+			}
+			continue
+		}
+		for k, field := range obj {
+			if _, taken := out[k]; !taken {
+				out[k] = field
+			}
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package transform
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		name string
+		in   map[string]any
+		want map[string]any
+	}{
+		{"filter",
+			map[string]any{"user": map[string]any{"id": "1"}, "orders": nil, "inventory": map[string]any{}, "notifications": map[string]any{"maintenance": true}},
+			map[string]any{"user": map[string]any{"id": "1"}}},
+		{"flatten",
+			map[string]any{"user": map[string]any{"id": "1", "name": "Ada"}, "count": 3.0},
+			map[string]any{"user.id": "1", "user.name": "Ada", "count": 3.0}},
+		{"compact",
+			map[string]any{"user": map[string]any{"id": "1", "email": nil}, "orders": map[string]any{"total": nil}, "gone": nil},
+			map[string]any{"user": map[string]any{"id": "1"}}},
+		{"reduce",
+			map[string]any{"user": map[string]any{"id": "u1", "name": "Ada"}, "orders": map[string]any{"id": "o1", "total": 2.0}, "count": 3.0},
+			map[string]any{"id": "o1", "name": "Ada", "total": 2.0, "count": 3.0}}, // orders sorts before user
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := Pipeline(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := fn(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("%s(%v) = %v, want %v", tt.name, tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPipelineAppliesStagesInOrder(t *testing.T) {
+	data := map[string]any{"user": map[string]any{"id": "1", "email": nil}}
+
+	// compact then flatten drops the null before lifting the fields.
+	fn, err := Pipeline("compact", "flatten")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := fn(data)
+	if want := map[string]any{"user.id": "1"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("compact,flatten = %v, want %v", got, want)
+	}
+
+	// Each stage runs once per mention, in the order named.
+	var seen []string
+	Register("record-a", func(d map[string]any) (map[string]any, error) { seen = append(seen, "a"); return d, nil })
+	Register("record-b", func(d map[string]any) (map[string]any, error) { seen = append(seen, "b"); return d, nil })
+	fn, err = Pipeline("record-b", "record-a", "record-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn(data)
+	if !reflect.DeepEqual(seen, []string{"b", "a", "b"}) {
+		t.Fatalf("stages ran as %v, want b, a, b", seen)
+	}
+	if _, ok := data["user"].(map[string]any)["email"]; !ok {
+		t.Fatal("a transform modified its input")
+	}
+
+	if _, err := Pipeline("flatten", "nope"); !errors.Is(err, ErrUnknownTransform) {
+		t.Fatalf("Pipeline(flatten, nope) = %v, want ErrUnknownTransform", err)
+	}
+}
@@ -0,0 +1,51 @@
+package transform
+
+import (
+	"context"
+	"strings"
+)
+
+// Variants picks how a service's responses are reshaped by the kind of client asking for them
+// (see WithClientType), e.g. a "mobile" template that keeps only the light fields. Clients with no
+// variant of their own get Default; a nil Default leaves their responses as they are.
+type Variants struct {
+	Default  *Template
+	ByClient map[string]*Template // client type, lower-case -> template
+}
+
+// Template returns the template for clientType, falling back to Default. It may return nil.
+func (v Variants) Template(clientType string) *Template {
+	if tmpl, ok := v.ByClient[strings.ToLower(clientType)]; ok {
+		return tmpl
+	}
+	return v.Default
+}
+
+// Wrap returns a fetcher (a service.Fetcher) whose successful responses are reshaped by the
+// template for the client type in the call's context.
+func (v Variants) Wrap(fetcher func(ctx context.Context, userID string) (any, error)) func(ctx context.Context, userID string) (any, error) {
+	return func(ctx context.Context, userID string) (any, error) {
+		data, err := fetcher(ctx, userID)
+		if err != nil {
+			return data, err
+		}
+		tmpl := v.Template(ClientType(ctx))
+		if tmpl == nil {
+			return data, nil
+		}
+		return tmpl.Apply(data)
+	}
+}
+
+type clientTypeKey struct{}
+
+// WithClientType returns a copy of ctx recording the kind of client the request is for, e.g. "mobile".
+func WithClientType(ctx context.Context, clientType string) context.Context {
+	return context.WithValue(ctx, clientTypeKey{}, clientType)
+}
+
+// ClientType returns the client type stored in ctx by WithClientType, or "" for none.
+func ClientType(ctx context.Context) string {
+	clientType, _ := ctx.Value(clientTypeKey{}).(string)
+	return clientType
+}